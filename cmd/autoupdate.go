@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const (
+	dbUpdateJobName       = "tldr-db-update"
+	autoUpdateStateFile   = "db-auto-update.json"
+	autoUpdateBaseBackoff = 10 * time.Minute
+	autoUpdateMaxBackoff  = 24 * time.Hour
+)
+
+// spawnDBUpdate starts a detached process to update the database. It is a variable for tests
+var spawnDBUpdate = func(c *client) error {
+	return startJob(c, dbUpdateJobName, "--"+longUpdateFlag, "--"+confirmFlag)
+}
+
+// startJob runs the workflow itself with `args` as a detached job
+func startJob(c *client, name string, args ...string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(self, args...)
+	_, err = c.Job(name).Logging().Start(cmd)
+	return err
+}
+
+// autoUpdateState records failures of updates not to retry on every query while offline
+type autoUpdateState struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// backoff returns the interval to the next attempt which doubles at every failure
+func (s *autoUpdateState) backoff() time.Duration {
+	if s.Failures <= 0 {
+		return 0
+	}
+	d := autoUpdateBaseBackoff
+	for i := 1; i < s.Failures && d < autoUpdateMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > autoUpdateMaxBackoff {
+		d = autoUpdateMaxBackoff
+	}
+	return d
+}
+
+func (s *autoUpdateState) ready(now time.Time) bool {
+	return now.Sub(s.LastFailure) >= s.backoff()
+}
+
+func autoUpdateStatePath(c *client) string {
+	return filepath.Join(c.GetCacheDir(), autoUpdateStateFile)
+}
+
+func loadAutoUpdateState(path string) *autoUpdateState {
+	s := &autoUpdateState{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return &autoUpdateState{}
+	}
+	return s
+}
+
+// recordDBUpdateResult resets the backoff on success and extends it on failure
+func recordDBUpdateResult(c *client, updateErr error) {
+	path := autoUpdateStatePath(c)
+	s := &autoUpdateState{}
+	if updateErr != nil {
+		s = loadAutoUpdateState(path)
+		s.Failures++
+		s.LastFailure = time.Now()
+	}
+
+	b, err := json.Marshal(s)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	}
+	if err == nil {
+		err = os.WriteFile(path, b, 0o600)
+	}
+	if err != nil {
+		c.Logger().Warnln("failed to save the auto update state", err)
+	}
+}
+
+// isAutoUpdateDBAllowed returns true if the database may be replaced in background.
+// Note a database rolled back by a user is kept until the next explicit update
+func isAutoUpdateDBAllowed(c *client) bool {
+	return c.cfg.fromEnv.isAutoUpdateDBEnabled && !c.tldrClient.RolledBack()
+}
+
+// autoUpdateDB starts the update in background and returns true if it started or is running.
+// It returns false while backing off after failures
+func autoUpdateDB(c *client) bool {
+	if c.Job(dbUpdateJobName).IsRunning() {
+		return true
+	}
+
+	s := loadAutoUpdateState(autoUpdateStatePath(c))
+	if !s.ready(time.Now()) {
+		c.Logger().Infoln("auto update is backing off after", s.Failures, "failures")
+		return false
+	}
+
+	if err := spawnDBUpdate(c); err != nil {
+		c.Logger().Warnln("failed to start auto update", err)
+		recordDBUpdateResult(c, err)
+		return false
+	}
+	c.Logger().Infoln("started auto update of tldr database")
+	return true
+}
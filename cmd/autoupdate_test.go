@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/konoui/alfred-tldr/pkg/tldr"
+	"github.com/konoui/go-alfred/env"
+)
+
+func Test_autoUpdateState(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		state       autoUpdateState
+		wantBackoff time.Duration
+		wantReady   bool
+	}{
+		{
+			name:        "no failures",
+			state:       autoUpdateState{},
+			wantBackoff: 0,
+			wantReady:   true,
+		},
+		{
+			name:        "backing off after a failure",
+			state:       autoUpdateState{Failures: 1, LastFailure: now.Add(-time.Minute)},
+			wantBackoff: autoUpdateBaseBackoff,
+			wantReady:   false,
+		},
+		{
+			name:        "backoff doubles",
+			state:       autoUpdateState{Failures: 3, LastFailure: now.Add(-time.Hour)},
+			wantBackoff: 4 * autoUpdateBaseBackoff,
+			wantReady:   true,
+		},
+		{
+			name:        "backoff is capped",
+			state:       autoUpdateState{Failures: 100, LastFailure: now.Add(-time.Hour)},
+			wantBackoff: autoUpdateMaxBackoff,
+			wantReady:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.backoff(); got != tt.wantBackoff {
+				t.Errorf("want: %v, got: %v", tt.wantBackoff, got)
+			}
+			if got := tt.state.ready(now); got != tt.wantReady {
+				t.Errorf("want: %v, got: %v", tt.wantReady, got)
+			}
+		})
+	}
+}
+
+func TestAutoUpdate(t *testing.T) {
+	const recommendation = "Tldr database is older than 2 weeks"
+	t.Setenv(envKeyDBAutoUpdate, "true")
+	t.Setenv(envKeyUpdateDBRecommendation, "true")
+	orgTTL, orgSpawn := twoWeeks, spawnDBUpdate
+	t.Cleanup(func() { twoWeeks, spawnDBUpdate = orgTTL, orgSpawn })
+	twoWeeks = 0
+
+	spawned := 0
+	var spawnErr error
+	spawnDBUpdate = func(c *client) error {
+		spawned++
+		return spawnErr
+	}
+	writeState := func(s *autoUpdateState) {
+		t.Helper()
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(os.Getenv(env.KeyWorkflowCache), autoUpdateStateFile)
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("start update in background", func(t *testing.T) {
+		spawned = 0
+		awf, cmd, outBuf, _ := setup(t, "lsof")
+		execute(t, awf, cmd, 0)
+		got := outBuf.String()
+		if strings.Contains(got, recommendation) {
+			t.Errorf("recommendation should not be shown: %v", got)
+		}
+		if !strings.Contains(got, "lsof") {
+			t.Errorf("page should be shown from the current database: %v", got)
+		}
+		if spawned != 1 {
+			t.Errorf("want: 1 spawn, got: %d", spawned)
+		}
+	})
+
+	t.Run("backing off after failures", func(t *testing.T) {
+		spawned = 0
+		awf, cmd, outBuf, _ := setup(t, "lsof")
+		writeState(&autoUpdateState{Failures: 1, LastFailure: time.Now()})
+		execute(t, awf, cmd, 0)
+		if got := outBuf.String(); !strings.Contains(got, recommendation) {
+			t.Errorf("recommendation should be shown: %v", got)
+		}
+		if spawned != 0 {
+			t.Errorf("want: no spawn, got: %d", spawned)
+		}
+	})
+
+	t.Run("failed to start update", func(t *testing.T) {
+		spawned, spawnErr = 0, errors.New("error")
+		t.Cleanup(func() { spawnErr = nil })
+		awf, cmd, outBuf, _ := setup(t, "lsof")
+		execute(t, awf, cmd, 0)
+		if got := outBuf.String(); !strings.Contains(got, recommendation) {
+			t.Errorf("recommendation should be shown: %v", got)
+		}
+		s := loadAutoUpdateState(filepath.Join(os.Getenv(env.KeyWorkflowCache), autoUpdateStateFile))
+		if s.Failures != 1 {
+			t.Errorf("want: 1 failure, got: %d", s.Failures)
+		}
+	})
+
+	t.Run("update execution resets the backoff", func(t *testing.T) {
+		awf, cmd, _, _ := setup(t, "--update --confirm")
+		writeState(&autoUpdateState{Failures: 3, LastFailure: time.Now()})
+		execute(t, awf, cmd, 0)
+		s := loadAutoUpdateState(filepath.Join(os.Getenv(env.KeyWorkflowCache), autoUpdateStateFile))
+		if s.Failures != 0 {
+			t.Errorf("want: no failures, got: %d", s.Failures)
+		}
+	})
+}
+
+func TestConfigChanged(t *testing.T) {
+	t.Setenv("LANG", "")
+	t.Setenv("LANGUAGE", "")
+	orgSpawn := spawnDBUpdate
+	t.Cleanup(func() { spawnDBUpdate = orgSpawn })
+	spawned := 0
+	spawnDBUpdate = func(c *client) error {
+		spawned++
+		return nil
+	}
+
+	archiveDir := t.TempDir()
+	f, err := os.Create(filepath.Join(archiveDir, "tldr-pages.en.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("common/lsof.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("# lsof\n\n> List open files.\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	urlTemplate := filepath.Join(archiveDir, "tldr-pages.{lang}.zip")
+	dataDir := t.TempDir()
+	tc := tldr.New(filepath.Join(dataDir, "data"), tldr.WithLanguageArchives(urlTemplate))
+	if _, err := tc.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(envKeyDBLanguageArchives, urlTemplate)
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	tests := []struct {
+		name        string
+		autoUpdate  string
+		wantSpawned int
+		want        string
+	}{
+		{
+			name:        "start update in background",
+			autoUpdate:  "true",
+			wantSpawned: 1,
+		},
+		{
+			name:       "recommend update",
+			autoUpdate: "false",
+			want:       "Tldr database does not match the configuration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawned = 0
+			t.Setenv(envKeyDBAutoUpdate, tt.autoUpdate)
+			t.Setenv(envKeyUpdateDBRecommendation, "true")
+			awf, cmd, outBuf, _ := setup(t, "lsof")
+			t.Setenv(env.KeyWorkflowData, dataDir)
+			execute(t, awf, cmd, 0)
+			got := outBuf.String()
+			if !strings.Contains(got, "List open files.") {
+				t.Errorf("page should be shown from the current database: %v", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("want: %v\n got: %v", tt.want, got)
+			}
+			if spawned != tt.wantSpawned {
+				t.Errorf("want: %d spawn, got: %d", tt.wantSpawned, spawned)
+			}
+			m, err := tldr.New(filepath.Join(dataDir, "data")).Manifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{"en"}, m.Languages); diff != "" {
+				t.Errorf("archives should not be fetched in the query: -want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAutoUpdateAfterRollback(t *testing.T) {
+	const recommendation = "Tldr database is older than 2 weeks"
+	t.Setenv(envKeyDBAutoUpdate, "true")
+	t.Setenv(envKeyUpdateDBRecommendation, "true")
+	orgTTL, orgSpawn := twoWeeks, spawnDBUpdate
+	t.Cleanup(func() { twoWeeks, spawnDBUpdate = orgTTL, orgSpawn })
+	twoWeeks = 0
+	spawned := 0
+	spawnDBUpdate = func(c *client) error {
+		spawned++
+		return nil
+	}
+
+	// Note switching the storage mode downloads the database again
+	dataDir := t.TempDir()
+	for _, zipStorage := range []bool{false, true} {
+		opts := []tldr.Option{tldr.WithRepositoryURL(testServer.TldrZipURL()), tldr.WithSnapshots(2)}
+		if zipStorage {
+			opts = append(opts, tldr.WithZipStorage())
+		}
+		tc := tldr.New(filepath.Join(dataDir, "data"), opts...)
+		if _, err := tc.Update(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		tc.Close()
+	}
+	tc := tldr.New(filepath.Join(dataDir, "data"))
+	snapshots, err := tc.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("want: 2 snapshots, got: %d", len(snapshots))
+	}
+	if err := tc.Rollback(snapshots[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	awf, cmd, outBuf, _ := setup(t, "lsof")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if spawned != 0 {
+		t.Errorf("the rolled back database should not be updated automatically: %d spawns", spawned)
+	}
+	if got := outBuf.String(); !strings.Contains(got, recommendation) {
+		t.Errorf("recommendation should be shown: %v", got)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build bundle
+
+package cmd
+
+import (
+	_ "embed"
+)
+
+// bundledDB is a snapshot of English pages used until the first database update succeeds.
+// Run `make setup-bundle` before building with the bundle tag.
+//
+//go:embed bundle/tldr.zip
+var bundledDB []byte
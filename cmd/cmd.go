@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -19,9 +20,11 @@ var (
 	twoWeeks                   = 2 * 7 * 24 * time.Hour
 	updateDBTimeout            = 30 * time.Second
 	updateWorkflowTimeout      = 1 * time.Minute
-	updateWorkflowCheckTimeout = 5 * time.Second
+	updateWorkflowCheckTimeout = 30 * time.Second
 )
 
+const timeFormat = "2006-01-02 15:04"
+
 var (
 	defaultPlatform = tldr.PlatformOSX
 	defaultOpts     = []alfred.Option{
@@ -48,6 +51,13 @@ const (
 	confirmFlag        = "confirm"
 	fuzzyFlag          = "fuzzy"
 	updateWorkflowFlag = "update-workflow"
+	infoFlag           = "info"
+	reindexFlag        = "reindex"
+	rollbackFlag       = "rollback"
+	checkWorkflowFlag  = "check-workflow"
+	installFlag        = "install"
+	pruneFlag          = "prune"
+	whatsNewFlag       = "whats-new"
 )
 
 var (
@@ -86,9 +96,17 @@ func NewRootCmd(cfg *Config, awf *alfred.Workflow) *cobra.Command {
 
 			tc, err := newTldrClient(cfg, awf)
 			if err != nil {
+				if title, ok := verificationWarning(err); ok {
+					awf.Logger().Errorln(err)
+					awf.SetEmptyWarning(title,
+						"the downloaded database was not installed").
+						Output()
+					return nil
+				}
 				return err
 			}
 
+			defer tc.Close()
 			c.tldrClient = tc
 			// update and normalize
 			args = c.UpdateOpts(alfred.WithArguments(args...)).Args()
@@ -98,8 +116,22 @@ func NewRootCmd(cfg *Config, awf *alfred.Workflow) *cobra.Command {
 				return printVersion(c, version, revision)
 			case cfg.updateWorkflow:
 				return updateTLDRWorkflow(c)
+			case cfg.checkWorkflow:
+				return checkWorkflowUpdate(c)
+			case cfg.info:
+				return printInfo(c)
 			case cfg.update:
 				return updateDB(c)
+			case cfg.reindex:
+				return reindexDB(c)
+			case cfg.rollback:
+				return rollbackDB(c, args)
+			case cfg.install:
+				return installDB(c, args)
+			case cfg.prune:
+				return pruneDB(c)
+			case cfg.whatsNew:
+				return printWhatsNew(c)
 			default:
 				return printPage(c, args)
 			}
@@ -120,6 +152,13 @@ func NewRootCmd(cfg *Config, awf *alfred.Workflow) *cobra.Command {
 	rootCmd.PersistentFlags().BoolVar(&cfg.confirm, confirmFlag, false, "confirmation for update")
 	rootCmd.PersistentFlags().BoolVar(&cfg.fuzzy, fuzzyFlag, false, "use fuzzy search")
 	rootCmd.PersistentFlags().BoolVar(&cfg.updateWorkflow, updateWorkflowFlag, false, "update tldr workflow if possible")
+	rootCmd.PersistentFlags().BoolVar(&cfg.checkWorkflow, checkWorkflowFlag, false, "check a newer tldr workflow in background")
+	rootCmd.PersistentFlags().BoolVar(&cfg.info, infoFlag, false, "show tldr database information")
+	rootCmd.PersistentFlags().BoolVar(&cfg.reindex, reindexFlag, false, "rebuild the index of tldr database")
+	rootCmd.PersistentFlags().BoolVar(&cfg.rollback, rollbackFlag, false, "roll back tldr database to a snapshot")
+	rootCmd.PersistentFlags().BoolVar(&cfg.install, installFlag, false, "install tldr database from a local zip")
+	rootCmd.PersistentFlags().BoolVar(&cfg.prune, pruneFlag, false, "remove unused languages from tldr database")
+	rootCmd.PersistentFlags().BoolVar(&cfg.whatsNew, whatsNewFlag, false, "show pages added and changed by the last update")
 
 	rootCmd.SetUsageFunc(getUsageFunc(c))
 	rootCmd.SetHelpFunc(getHelpFunc(c))
@@ -131,6 +170,20 @@ func NewRootCmd(cfg *Config, awf *alfred.Workflow) *cobra.Command {
 	return rootCmd
 }
 
+// verificationWarning returns a warning title if the database failed to be verified
+func verificationWarning(err error) (string, bool) {
+	switch {
+	case errors.Is(err, tldr.ErrChecksumMismatch):
+		return "Tldr database checksum mismatch", true
+	case errors.Is(err, tldr.ErrSignatureMissing):
+		return "Tldr database is not signed", true
+	case errors.Is(err, tldr.ErrInvalidSignature):
+		return "Tldr database has an invalid signature", true
+	default:
+		return "", false
+	}
+}
+
 func getHelpFunc(c *client) func(*cobra.Command, []string) {
 	return func(cmd *cobra.Command, args []string) {
 		usageFunc := getUsageFunc(c)
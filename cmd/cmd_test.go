@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -456,6 +462,460 @@ func TestUpdateExecution(t *testing.T) {
 	}
 }
 
+func Test_printDBUpdateResults(t *testing.T) {
+	tests := []struct {
+		name string
+		res  *tldr.UpdateResult
+		err  error
+		want string
+	}{
+		{
+			name: "updated",
+			res:  &tldr.UpdateResult{SourceURL: "https://example.com/tldr.zip"},
+			want: "update succeeded from https://example.com/tldr.zip",
+		},
+		{
+			name: "not modified",
+			res:  &tldr.UpdateResult{SourceURL: "https://example.com/tldr.zip", NotModified: true},
+			want: "update succeeded, already up to date with https://example.com/tldr.zip",
+		},
+		{
+			name: "stale pages removed",
+			res: &tldr.UpdateResult{
+				SourceURL:    "https://example.com/tldr.zip",
+				RemovedPages: []string{"pages/common/a.md", "pages/linux/b.md"},
+			},
+			want: "update succeeded from https://example.com/tldr.zip, 2 stale pages removed",
+		},
+		{
+			name: "pages added and changed",
+			res: &tldr.UpdateResult{
+				SourceURL:    "https://example.com/tldr.zip",
+				AddedPages:   []string{"pages/common/a.md"},
+				ChangedPages: []string{"pages/common/b.md", "pages/linux/c.md"},
+				RemovedPages: []string{"pages/linux/d.md"},
+			},
+			want: "update succeeded from https://example.com/tldr.zip, 1 pages added, 2 pages changed, 1 stale pages removed",
+		},
+		{
+			name: "failed",
+			err:  errors.New("error"),
+			want: "update failed due to error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			_ = printDBUpdateResults(buf, tt.res, tt.err)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("want: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	t.Setenv(envKeyDBChecksum, strings.Repeat("0", 64))
+
+	t.Run("update execution outputs failed message", func(t *testing.T) {
+		awf, cmd, outBuf, _ := setup(t, "--update --confirm")
+		execute(t, awf, cmd, 0)
+		if got, want := outBuf.String(), "checksum mismatch"; !strings.Contains(got, want) {
+			t.Errorf("want: %v\n got: %v", want, got)
+		}
+	})
+
+	t.Run("initialization outputs warning item", func(t *testing.T) {
+		awf, cmd, outBuf, _ := setup(t, "lsof")
+		// use an empty data dir to download the database
+		t.Setenv(env.KeyWorkflowData, t.TempDir())
+		execute(t, awf, cmd, 0)
+		if got, want := outBuf.String(), "Tldr database checksum mismatch"; !strings.Contains(got, want) {
+			t.Errorf("want: %v\n got: %v", want, got)
+		}
+	})
+}
+
+func TestPrintInfo(t *testing.T) {
+	awf, cmd, outBuf, _ := setup(t, "--info")
+	execute(t, awf, cmd, 0)
+
+	got := outBuf.String()
+	for _, want := range []string{
+		testServer.TldrZipURL(),
+		"Downloaded at",
+		"pages in en",
+		"SHA256: ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %v\n got: %v", want, got)
+		}
+	}
+}
+
+func TestReindex(t *testing.T) {
+	awf, cmd, outBuf, _ := setup(t, "--reindex")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); !strings.Contains(got, "--reindex --confirm") {
+		t.Errorf("no confirmation item in %v", got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--reindex --confirm")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); !strings.Contains(got, "reindex succeeded with") {
+		t.Errorf("want: reindex succeeded, got: %v", got)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv(envKeyDBSnapshots, "2")
+
+	awf, cmd, outBuf, _ := setup(t, "--rollback")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "No snapshots to roll back"; !strings.Contains(got, want) {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	// Note switching the storage mode downloads the database again
+	tc := tldr.New(filepath.Join(dataDir, "data"),
+		tldr.WithRepositoryURL(testServer.TldrZipURL()), tldr.WithSnapshots(2), tldr.WithZipStorage())
+	if _, err := tc.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	tc.Close()
+	snapshots, err := tc.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("want: 2 snapshots, got: %d", len(snapshots))
+	}
+	prev := snapshots[1].ID
+
+	awf, cmd, outBuf, _ = setup(t, "--rollback")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	got := outBuf.String()
+	for _, want := range []string{"(current)", "--rollback --confirm " + prev} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %v\n got: %v", want, got)
+		}
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--rollback --confirm "+prev)
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "rollback succeeded to "+prev; got != want {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	dataDir := t.TempDir()
+	src, err := os.ReadFile(testServer.TldrZipPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(t.TempDir(), "air gapped", "tldr.zip")
+	if err := os.MkdirAll(filepath.Dir(zipPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(zipPath, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := "file://" + strings.ReplaceAll(filepath.ToSlash(zipPath), " ", "%20")
+
+	awf, cmd, outBuf, _ := setup(t, "--install "+zipPath)
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "--install --confirm "+fileURL; !strings.Contains(got, want) {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--install "+filepath.Join(t.TempDir(), "tldr.zip"))
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "Please input a path to tldr.zip"; !strings.Contains(got, want) {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--install --confirm "+fileURL)
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "update succeeded from "+fileURL; got != want {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	tc := tldr.New(filepath.Join(dataDir, "data"))
+	if _, err := tc.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("database is not installed: %v", err)
+	}
+}
+
+func TestInstallWithoutNetwork(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "tldr.zip")
+	src, err := os.ReadFile(testServer.TldrZipPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(zipPath, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := "file://" + filepath.ToSlash(zipPath)
+	dataDir := t.TempDir()
+
+	awf, _, outBuf, _ := setup(t, "--install --confirm "+fileURL)
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	// Note replace the test server with an unreachable url
+	cfg := NewConfig()
+	cfg.tldrOpts = append(cfg.tldrOpts, tldr.WithRepositoryURL("http://127.0.0.1:1/tldr.zip"))
+	cmd := NewRootCmd(cfg, awf)
+	cmd.SetOut(outBuf)
+	cmd.SetArgs([]string{"--install", "--confirm", fileURL})
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "update succeeded from "+fileURL; got != want {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	tc := tldr.New(filepath.Join(dataDir, "data"))
+	if _, err := tc.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("database is not installed: %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("LANG", "")
+	t.Setenv("LANGUAGE", "")
+	tc := tldr.New(filepath.Join(dataDir, "data"))
+	if _, err := tc.Install(context.TODO(), testServer.TldrZipPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	awf, cmd, outBuf, _ := setup(t, "--prune")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	got := outBuf.String()
+	for _, want := range []string{"Please Enter if remove 1 languages", "--prune --confirm", "in ja", "(remove)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %v\n got: %v", want, got)
+		}
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--prune --confirm")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "prune succeeded, removed ja"; got != want {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--prune")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "No languages to remove"; !strings.Contains(got, want) {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+}
+
+func TestReleaseTag(t *testing.T) {
+	zip, err := os.ReadFile(testServer.TldrZipPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2.0/tldr.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(zip)
+	}))
+	t.Cleanup(srv.Close)
+
+	dataDir := t.TempDir()
+	tc := tldr.New(filepath.Join(dataDir, "data"), tldr.WithReleaseTag("v2.0"), tldr.WithReleaseBaseURL(srv.URL))
+	if _, err := tc.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envKeyDBReleaseTag, "v2.0")
+
+	awf, cmd, outBuf, _ := setup(t, "-v")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), `"title":"alfred-tldr *(*) with tldr-pages v2.0"`; !strings.Contains(got, want) {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+}
+
+func TestWhatsNew(t *testing.T) {
+	t.Setenv("LANG", "")
+	t.Setenv("LANGUAGE", "")
+	dataDir := t.TempDir()
+
+	awf, cmd, outBuf, _ := setup(t, "--whats-new")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	if got, want := outBuf.String(), "No changes by the last update"; !strings.Contains(got, want) {
+		t.Errorf("want: %v\n got: %v", want, got)
+	}
+
+	zr, err := zip.OpenReader(testServer.TldrZipPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	zipPath := filepath.Join(t.TempDir(), "tldr.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, file := range zr.File {
+		w, err := zw.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.Name == "pages/common/lsof.md" {
+			_, err = w.Write([]byte("# lsof\n\n> Updated page.\n"))
+		} else {
+			err = copyZipFile(w, file)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := zw.Create("pages/linux/mytool.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("# mytool\n\n> A new page.\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	res, err := tldr.New(filepath.Join(dataDir, "data")).Install(context.TODO(), zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(res.AddedPages), 1; got != want {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--whats-new")
+	t.Setenv(env.KeyWorkflowData, dataDir)
+	execute(t, awf, cmd, 0)
+	got := outBuf.String()
+	for _, want := range []string{
+		`"title":"1 pages added, 1 pages changed, 0 pages removed"`,
+		`"title":"mytool","subtitle":"New in linux (en)"`,
+		`"autocomplete":"-p linux mytool"`,
+		`"title":"lsof","subtitle":"Updated in common (en)"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want: %v\n got: %v", want, got)
+		}
+	}
+}
+
+func copyZipFile(w io.Writer, file *zip.File) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func TestCustomPages(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pages", "common"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	page := "# mytool\n\n> An internal tool.\n\n- Run it:\n\n`mytool {{arg}}`\n"
+	if err := os.WriteFile(filepath.Join(dir, "pages", "common", "mytool.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envKeyCustomPagesDirs, dir)
+
+	awf, cmd, outBuf, _ := setup(t, "mytoo --fuzzy")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); !strings.Contains(got, "[custom] Platforms: [common]") {
+		t.Errorf("no custom marker in %v", got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "mytool")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); !strings.Contains(got, "mytool {arg}") {
+		t.Errorf("no custom page in %v", got)
+	}
+}
+
+func TestFuzzySuggestionsInLanguages(t *testing.T) {
+	t.Setenv("LANG", "")
+	t.Setenv("LANGUAGE", "")
+	dir := t.TempDir()
+	for _, name := range []string{
+		"pages/linux/mytool.md",
+		"pages.fr/osx/mytool.md",
+		"pages.fr/common/frtool.md",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("# tool\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(envKeyCustomPagesDirs, dir)
+
+	awf, cmd, outBuf, _ := setup(t, "mytoo --fuzzy")
+	execute(t, awf, cmd, 0)
+	got := outBuf.String()
+	if !strings.Contains(got, "[custom] Platforms: [linux]") {
+		t.Errorf("platforms without English pages should not be suggested: %v", got)
+	}
+	if !strings.Contains(got, "-p linux mytool") {
+		t.Errorf("want autocomplete with linux: %v", got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "frtoo --fuzzy")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); strings.Contains(got, "frtool") {
+		t.Errorf("command without English pages should not be suggested: %v", got)
+	}
+}
+
+func TestLocalRepository(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "pages", "common"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	page := "# in-review\n\n> Not released yet.\n\n- Run it:\n\n`in-review`\n"
+	if err := os.WriteFile(filepath.Join(repo, "pages", "common", "in-review.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envKeyLocalRepository, repo)
+
+	awf, cmd, outBuf, _ := setup(t, "in-revi --fuzzy")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); !strings.Contains(got, `"title":"in-review"`) {
+		t.Errorf("no page in the local repository: %v", got)
+	}
+
+	awf, cmd, outBuf, _ = setup(t, "--update --confirm")
+	execute(t, awf, cmd, 0)
+	if got := outBuf.String(); !strings.Contains(got, "update failed") {
+		t.Errorf("local repository should not be updated: %v", got)
+	}
+}
+
 func Test_choicePlatform(t *testing.T) {
 	type args struct {
 		pts      []tldr.Platform
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
 	"path/filepath"
 
 	"github.com/konoui/alfred-tldr/pkg/tldr"
@@ -14,6 +21,21 @@ type envs struct {
 	modKeyOpenURL                    alfred.ModKey
 	isUpdateWorkflowRecommendEnabled bool
 	isUpdateDBRecommendEnabled       bool
+	isAutoUpdateDBEnabled            bool
+	repositoryURLs                   []string
+	httpProxy                        string
+	caFile                           string
+	httpHeaders                      string
+	dbChecksum                       string
+	isDBChecksumVerificationEnabled  bool
+	dbPublicKey                      string
+	isZipStorageEnabled              bool
+	customPagesDirs                  []string
+	localRepository                  string
+	dbSnapshots                      int
+	languageArchiveURL               string
+	keepLanguages                    []string
+	releaseTag                       string
 }
 
 type Config struct {
@@ -21,9 +43,16 @@ type Config struct {
 	language       string
 	update         bool
 	updateWorkflow bool
+	checkWorkflow  bool
 	confirm        bool
 	fuzzy          bool
 	version        bool
+	info           bool
+	reindex        bool
+	rollback       bool
+	install        bool
+	prune          bool
+	whatsNew       bool
 	fromEnv        envs
 	tldrOpts       []tldr.Option
 }
@@ -33,7 +62,22 @@ func NewConfig() *Config {
 	cfg.fromEnv.formatFunc = getCommandFormatFunc()
 	cfg.fromEnv.modKeyOpenURL = getModKeyOpenURL()
 	cfg.fromEnv.isUpdateDBRecommendEnabled = isUpdateDBRecommendEnabled()
+	cfg.fromEnv.isAutoUpdateDBEnabled = parseBool(envKeyDBAutoUpdate)
 	cfg.fromEnv.isUpdateWorkflowRecommendEnabled = isUpdateWorkflowRecommendEnabled()
+	cfg.fromEnv.repositoryURLs = getRepositoryURLs()
+	cfg.fromEnv.httpProxy = os.Getenv(envKeyHTTPProxy)
+	cfg.fromEnv.caFile = os.Getenv(envKeyCAFile)
+	cfg.fromEnv.httpHeaders = os.Getenv(envKeyHTTPHeaders)
+	cfg.fromEnv.dbChecksum = os.Getenv(envKeyDBChecksum)
+	cfg.fromEnv.isDBChecksumVerificationEnabled = parseBool(envKeyDBChecksumVerification)
+	cfg.fromEnv.dbPublicKey = os.Getenv(envKeyDBPublicKey)
+	cfg.fromEnv.isZipStorageEnabled = isZipStorageEnabled()
+	cfg.fromEnv.customPagesDirs = getCustomPagesDirs()
+	cfg.fromEnv.localRepository = getLocalRepository()
+	cfg.fromEnv.dbSnapshots = getDBSnapshots()
+	cfg.fromEnv.languageArchiveURL = getLanguageArchiveURL()
+	cfg.fromEnv.keepLanguages = getKeepLanguages()
+	cfg.fromEnv.releaseTag = getReleaseTag()
 	return cfg
 }
 
@@ -54,13 +98,122 @@ func (cfg *Config) setPlatform(ptString string) error {
 	return fmt.Errorf("%s is unsupported platform", ptString)
 }
 
+// httpOptions returns options for downloading from workflow environment variables
+func (cfg *Config) httpOptions() ([]tldr.Option, error) {
+	var opts []tldr.Option
+	headers, err := parseHTTPHeaders(cfg.fromEnv.httpHeaders)
+	if err != nil {
+		return nil, err
+	}
+	for host, header := range headers {
+		for key, values := range header {
+			for _, v := range values {
+				opts = append(opts, tldr.WithHTTPHeader(host, key, v))
+			}
+		}
+	}
+
+	if cfg.fromEnv.httpProxy == "" && cfg.fromEnv.caFile == "" {
+		return opts, nil
+	}
+	client, err := newHTTPClient(cfg.fromEnv.httpProxy, cfg.fromEnv.caFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, tldr.WithHTTPClient(client)), nil
+}
+
+// newHTTPClient returns a client with a proxy and a CA bundle appended to system roots
+func newHTTPClient(proxyURL, caFile string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url in %s: %w", envKeyHTTPProxy, err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file in %s: %w", envKeyCAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA file %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
 func newTldrClient(cfg *Config, awf *alfred.Workflow) (*tldr.Tldr, error) {
 	path := filepath.Join(awf.GetDataDir(), "data")
 
-	opts := append([]tldr.Option{
+	opts := []tldr.Option{
 		tldr.WithPlatform(cfg.platform),
 		tldr.WithLanguage(cfg.language),
-	}, cfg.tldrOpts...)
+		tldr.WithClientVersion(version),
+	}
+	if cfg.install {
+		// Note the database is installed from a local file without network access
+		opts = append(opts, tldr.WithoutInitialUpdate())
+	}
+	if n := cfg.fromEnv.dbSnapshots; n > 0 {
+		opts = append(opts, tldr.WithSnapshots(n))
+	}
+	if len(bundledDB) > 0 {
+		zr, err := zip.NewReader(bytes.NewReader(bundledDB), int64(len(bundledDB)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundled database: %w", err)
+		}
+		opts = append(opts, tldr.WithFallbackStorage(zr))
+	}
+	if cfg.fromEnv.isZipStorageEnabled {
+		opts = append(opts, tldr.WithZipStorage())
+	}
+	if dir := cfg.fromEnv.localRepository; dir != "" {
+		opts = append(opts, tldr.WithLocalRepository(dir))
+	}
+	if dirs := cfg.fromEnv.customPagesDirs; len(dirs) > 0 {
+		opts = append(opts, tldr.WithOverlayDirs(dirs...))
+	}
+	if tag := cfg.fromEnv.releaseTag; tag != "" {
+		opts = append(opts, tldr.WithReleaseTag(tag))
+	}
+	if u := cfg.fromEnv.languageArchiveURL; u != "" {
+		opts = append(opts, tldr.WithLanguageArchives(u))
+	}
+	if urls := cfg.fromEnv.repositoryURLs; len(urls) > 0 {
+		opts = append(opts, tldr.WithRepositoryURLs(urls...))
+	}
+	if sum := cfg.fromEnv.dbChecksum; sum != "" {
+		opts = append(opts, tldr.WithChecksum(sum))
+	}
+	if cfg.fromEnv.isDBChecksumVerificationEnabled {
+		opts = append(opts, tldr.WithChecksumVerification())
+	}
+	if v := cfg.fromEnv.dbPublicKey; v != "" {
+		pk, err := tldr.ParsePublicKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key in %s: %w", envKeyDBPublicKey, err)
+		}
+		opts = append(opts, tldr.WithPublicKey(pk))
+	}
+	httpOpts, err := cfg.httpOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, httpOpts...)
+	opts = append(opts, cfg.tldrOpts...)
 
 	tldrClient := tldr.New(path, opts...)
 	ctx, cancel := context.WithTimeout(context.Background(), updateDBTimeout)
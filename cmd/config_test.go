@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/konoui/alfred-tldr/pkg/tldr"
+)
+
+func Test_parseHTTPHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      map[string]http.Header
+		expectErr bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  map[string]http.Header{},
+		},
+		{
+			name:  "multiple headers",
+			value: "tldr.example.com=Authorization: Bearer token==; tldr.example.com=x-team: tools ; localhost:8080=X-Team: dev;",
+			want: map[string]http.Header{
+				"tldr.example.com": {
+					"Authorization": []string{"Bearer token=="},
+					"X-Team":        []string{"tools"},
+				},
+				"localhost:8080": {
+					"X-Team": []string{"dev"},
+				},
+			},
+		},
+		{
+			name:      "invalid format",
+			value:     "tldr.example.com=Authorization",
+			expectErr: true,
+		},
+		{
+			name:      "no host",
+			value:     "Authorization: Bearer token==",
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHTTPHeaders(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expect error happens, but got response")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error got: %+v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_newHTTPClient(t *testing.T) {
+	t.Run("trust custom CA", func(t *testing.T) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		t.Cleanup(ts.Close)
+
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		block := &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}
+		if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := newHTTPClient("", caFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("unexpected error got: %+v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("route through proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+		}))
+		t.Cleanup(proxy.Close)
+
+		client, err := newHTTPClient(proxy.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get("http://tldr.example.com/tldr.zip")
+		if err != nil {
+			t.Fatalf("unexpected error got: %+v", err)
+		}
+		resp.Body.Close()
+		if want := "http://tldr.example.com/tldr.zip"; proxied != want {
+			t.Errorf("want: %s, got: %s", want, proxied)
+		}
+	})
+
+	t.Run("invalid CA file", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(caFile, []byte("invalid"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := newHTTPClient("", caFile); err == nil {
+			t.Errorf("expect error happens, but got response")
+		}
+	})
+}
+
+func Test_getCustomPagesDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "empty",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "multiple dirs",
+			value: "/opt/tldr: ~/tldr-custom:",
+			want:  []string{"/opt/tldr", filepath.Join(home, "tldr-custom")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envKeyCustomPagesDirs, tt.value)
+			got := getCustomPagesDirs()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_getLanguageArchiveURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "disabled by default",
+			value: "",
+			want:  "",
+		},
+		{
+			name:  "disabled explicitly",
+			value: "false",
+			want:  "",
+		},
+		{
+			name:  "default url",
+			value: "true",
+			want:  tldr.LanguageArchiveURL,
+		},
+		{
+			name:  "custom url",
+			value: "https://tldr.example.com/tldr-pages.{lang}.zip",
+			want:  "https://tldr.example.com/tldr-pages.{lang}.zip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envKeyDBLanguageArchives, tt.value)
+			if got := getLanguageArchiveURL(); got != tt.want {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !bundle
+
+package cmd
+
+// bundledDB is empty unless the binary is built with the bundle tag
+var bundledDB []byte
@@ -1,11 +1,12 @@
 package cmd
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/konoui/alfred-tldr/pkg/tldr"
 	"github.com/konoui/go-alfred"
@@ -13,9 +14,7 @@ import (
 
 func printPage(c *client, cmds []string) error {
 	// insert update recommendation first
-	ctx, cancel := context.WithTimeout(context.Background(), updateWorkflowCheckTimeout)
-	defer cancel()
-	if c.cfg.fromEnv.isUpdateWorkflowRecommendEnabled && c.Updater().IsNewVersionAvailable(ctx) {
+	if c.cfg.fromEnv.isUpdateWorkflowRecommendEnabled && isNewerWorkflowAvailable(c) {
 		c.Append(
 			alfred.NewItem().
 				Title("Newer tldr wrokflow is available!").
@@ -26,10 +25,29 @@ func printPage(c *client, cmds []string) error {
 		)
 	}
 
-	if c.cfg.fromEnv.isUpdateDBRecommendEnabled && c.tldrClient.Expired(twoWeeks) {
+	if at, ok := c.tldrClient.Fallback(); ok {
+		if isAutoUpdateDBAllowed(c) {
+			autoUpdateDB(c)
+		}
+		days := int(time.Since(at).Hours() / 24)
+		c.Append(
+			alfred.NewItem().
+				Title(fmt.Sprintf("Bundled tldr database (%d days old) is in use", days)).
+				Subtitle("Please Enter to download the latest database").
+				Arg(fmt.Sprintf("--%s --%s", longUpdateFlag, confirmFlag)).
+				Icon(c.Asseter().IconAlertNote()).
+				Variable(nextActionKey, nextActionShell),
+		)
+	} else if changed := c.tldrClient.ConfigChanged(); (changed || c.tldrClient.Expired(twoWeeks)) &&
+		!(isAutoUpdateDBAllowed(c) && autoUpdateDB(c)) &&
+		c.cfg.fromEnv.isUpdateDBRecommendEnabled {
+		title := "Tldr database is older than 2 weeks"
+		if changed {
+			title = "Tldr database does not match the configuration"
+		}
 		c.Append(
 			alfred.NewItem().
-				Title("Tldr database is older than 2 weeks").
+				Title(title).
 				Subtitle("Please Enter!").
 				Arg(fmt.Sprintf("--%s --%s", longUpdateFlag, confirmFlag)).
 				Icon(c.Asseter().IconAlertNote()).
@@ -125,10 +143,16 @@ func printFuzzyPages(c *client, cmds []string) error {
 		return err
 	}
 
+	langs := c.tldrClient.Languages()
 	suggestions := index.Commands.Search(cmds)
 	for _, cmd := range suggestions {
+		// Note suggest only platforms which have a page in the languages
+		pts := cmd.PlatformsIn(langs...)
+		if len(pts) == 0 {
+			continue
+		}
 		complete := cmd.Name
-		pt := choicePlatform(cmd.Platforms, c.cfg.platform)
+		pt := choicePlatform(pts, c.cfg.platform)
 		if pt != tldr.PlatformCommon && pt != defaultPlatform {
 			complete = fmt.Sprintf("-%s %s %s",
 				platformFlag,
@@ -136,10 +160,14 @@ func printFuzzyPages(c *client, cmds []string) error {
 				cmd.Name,
 			)
 		}
+		subtitle := fmt.Sprintf("Platforms: %s", fmt.Sprintf("%s", pts))
+		if cmd.Custom {
+			subtitle = "[custom] " + subtitle
+		}
 		c.Append(
 			alfred.NewItem().
 				Title(cmd.Name).
-				Subtitle(fmt.Sprintf("Platforms: %s", fmt.Sprintf("%s", cmd.Platforms))).
+				Subtitle(subtitle).
 				Valid(false).
 				Autocomplete(complete).
 				Icon(
@@ -155,12 +183,140 @@ func printFuzzyPages(c *client, cmds []string) error {
 
 func printVersion(c *client, v, r string) (_ error) {
 	title := fmt.Sprintf("alfred-tldr %v(%s)", v, r)
+	if m, err := c.tldrClient.Manifest(); err == nil && m.ReleaseTag != "" {
+		title += fmt.Sprintf(" with tldr-pages %s", m.ReleaseTag)
+	}
 	c.Append(
 		alfred.NewItem().Title(title),
 	).Output()
 	return
 }
 
+func printInfo(c *client) error {
+	m, err := c.tldrClient.Manifest()
+	if err != nil {
+		c.Logger().Infoln(err)
+		c.SetEmptyWarning(
+			"No tldr database information",
+			"Please update the tldr database",
+		).Output()
+		return nil
+	}
+
+	c.Append(
+		alfred.NewItem().
+			Title(fmt.Sprintf("Downloaded at %s", m.DownloadedAt.Format(timeFormat))).
+			Subtitle(fmt.Sprintf("Checked at %s", m.CheckedAt.Format(timeFormat))).
+			Valid(false),
+		alfred.NewItem().
+			Title(m.SourceURL).
+			Subtitle("Source URL").
+			Valid(false),
+		alfred.NewItem().
+			Title(fmt.Sprintf("Archive size %d bytes", m.ArchiveSize)).
+			Subtitle(fmt.Sprintf("SHA256: %s", m.ArchiveSHA256)).
+			Valid(false),
+	)
+	if m.ETag != "" {
+		c.Append(
+			alfred.NewItem().
+				Title(m.ETag).
+				Subtitle("ETag").
+				Valid(false),
+		)
+	}
+
+	langs := make([]string, 0, len(m.Pages))
+	for lang := range m.Pages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		total := 0
+		counts := make([]string, 0, len(m.Pages[lang]))
+		for pt, n := range m.Pages[lang] {
+			total += n
+			counts = append(counts, fmt.Sprintf("%s: %d", pt, n))
+		}
+		sort.Strings(counts)
+		c.Append(
+			alfred.NewItem().
+				Title(fmt.Sprintf("%d pages in %s", total, lang)).
+				Subtitle(strings.Join(counts, ", ")).
+				Valid(false),
+		)
+	}
+
+	if m.ClientVersion != "" {
+		c.Append(
+			alfred.NewItem().
+				Title(fmt.Sprintf("alfred-tldr %s", m.ClientVersion)).
+				Subtitle("Client version at download").
+				Valid(false),
+		)
+	}
+
+	c.Output()
+	return nil
+}
+
+func printWhatsNew(c *client) error {
+	r, err := c.tldrClient.WhatsNew()
+	if err != nil {
+		c.Logger().Infoln(err)
+		c.SetEmptyWarning(
+			"No changes by the last update",
+			"Please update the tldr database",
+		).Output()
+		return nil
+	}
+
+	c.Append(
+		alfred.NewItem().
+			Title(fmt.Sprintf("%d pages added, %d pages changed, %d pages removed",
+				len(r.Added), len(r.Changed), len(r.Removed))).
+			Subtitle(fmt.Sprintf("Updated at %s", r.UpdatedAt.Format(timeFormat))).
+			Valid(false),
+	)
+
+	// Note show only languages which are looked up
+	langs := c.tldrClient.Languages()
+	inLangs := make(map[string]bool, len(langs))
+	for _, lang := range langs {
+		inLangs[lang] = true
+	}
+	appendChanges := func(changes []tldr.PageChange, label string) {
+		for _, pc := range changes {
+			if !inLangs[pc.Language] {
+				continue
+			}
+			complete := pc.Command
+			if pc.Platform != tldr.PlatformCommon && pc.Platform != defaultPlatform {
+				complete = fmt.Sprintf("-%s %s %s", platformFlag, pc.Platform, complete)
+			}
+			if len(langs) > 0 && pc.Language != langs[0] {
+				complete = fmt.Sprintf("-%s %s %s", languageFlag, pc.Language, complete)
+			}
+			c.Append(
+				alfred.NewItem().
+					Title(pc.Command).
+					Subtitle(fmt.Sprintf("%s in %s (%s)", label, pc.Platform, pc.Language)).
+					Valid(false).
+					Autocomplete(complete).
+					Icon(
+						alfred.NewIcon().
+							Path("candidate.png"),
+					),
+			)
+		}
+	}
+	appendChanges(r.Added, "New")
+	appendChanges(r.Changed, "Updated")
+
+	c.Output()
+	return nil
+}
+
 func choicePlatform(pts []tldr.Platform, selected tldr.Platform) tldr.Platform {
 	if len(pts) >= 2 {
 		// if there are more than two platforms,
@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/konoui/alfred-tldr/pkg/tldr"
 	"github.com/konoui/go-alfred"
 )
 
@@ -18,6 +23,28 @@ func printUpdateResults(w io.Writer, err error) (_ error) {
 	return
 }
 
+func printDBUpdateResults(w io.Writer, res *tldr.UpdateResult, err error) (_ error) {
+	if err != nil {
+		return printUpdateResults(w, err)
+	}
+
+	if res.NotModified {
+		fmt.Fprintf(w, "update succeeded, already up to date with %s", res.SourceURL)
+	} else {
+		fmt.Fprintf(w, "update succeeded from %s", res.SourceURL)
+	}
+	if n := len(res.AddedPages); n > 0 {
+		fmt.Fprintf(w, ", %d pages added", n)
+	}
+	if n := len(res.ChangedPages); n > 0 {
+		fmt.Fprintf(w, ", %d pages changed", n)
+	}
+	if n := len(res.RemovedPages); n > 0 {
+		fmt.Fprintf(w, ", %d stale pages removed", n)
+	}
+	return
+}
+
 func updateTLDRWorkflow(c *client) error {
 	if c.cfg.confirm {
 		c.Logger().Infoln("updating tldr workflow...")
@@ -36,8 +63,14 @@ func updateDB(c *client) error {
 		c.Logger().Infoln("updating tldr database...")
 		ctx, cancel := context.WithTimeout(context.Background(), updateDBTimeout)
 		defer cancel()
-		err := c.tldrClient.Update(ctx)
-		return printUpdateResults(c.OutWriter(), err)
+		res, err := c.tldrClient.Update(ctx)
+		recordDBUpdateResult(c, err)
+		if err == nil {
+			for _, p := range res.RemovedPages {
+				c.Logger().Infoln("removed a stale page", p)
+			}
+		}
+		return printDBUpdateResults(c.OutWriter(), res, err)
 	}
 
 	c.Append(
@@ -50,3 +83,227 @@ func updateDB(c *client) error {
 
 	return nil
 }
+
+func printReindexResults(w io.Writer, index *tldr.CmdsIndex, err error) (_ error) {
+	if err != nil {
+		fmt.Fprintf(w, "reindex failed due to %s", err)
+	} else {
+		fmt.Fprintf(w, "reindex succeeded with %d commands", len(index.Commands))
+	}
+	return
+}
+
+func reindexDB(c *client) error {
+	if c.cfg.confirm {
+		c.Logger().Infoln("rebuilding the index of tldr database...")
+		index, err := c.tldrClient.Reindex()
+		return printReindexResults(c.OutWriter(), index, err)
+	}
+
+	c.Append(
+		alfred.NewItem().
+			Title("Please Enter if rebuild the index of tldr database").
+			Subtitle("the index is rebuilt from local pages").
+			Arg(fmt.Sprintf("--%s --%s", reindexFlag, confirmFlag)),
+	).
+		Variable(nextActionKey, nextActionShell).
+		Output()
+
+	return nil
+}
+
+func printRollbackResults(w io.Writer, id string, err error) (_ error) {
+	if err != nil {
+		fmt.Fprintf(w, "rollback failed due to %s", err)
+	} else {
+		fmt.Fprintf(w, "rollback succeeded to %s", id)
+	}
+	return
+}
+
+func rollbackDB(c *client, args []string) error {
+	if c.cfg.confirm {
+		if len(args) == 0 {
+			return printRollbackResults(c.OutWriter(), "", errors.New("no snapshot specified"))
+		}
+		c.Logger().Infoln("rolling back tldr database to", args[0])
+		err := c.tldrClient.Rollback(args[0])
+		return printRollbackResults(c.OutWriter(), args[0], err)
+	}
+
+	snapshots, err := c.tldrClient.Snapshots()
+	if err != nil {
+		c.Logger().Infoln(err)
+	}
+	if len(snapshots) <= 1 {
+		c.SetEmptyWarning(
+			"No snapshots to roll back",
+			fmt.Sprintf("please set %s to keep previous databases", envKeyDBSnapshots),
+		).Output()
+		return nil
+	}
+
+	for _, s := range snapshots {
+		title := fmt.Sprintf("Downloaded at %s", s.DownloadedAt.Format(timeFormat))
+		if s.DownloadedAt.IsZero() {
+			title = s.ID
+		}
+		item := alfred.NewItem().
+			Title(title).
+			Subtitle(s.SourceURL)
+		if s.Current {
+			item.Title(title + " (current)").Valid(false)
+		} else {
+			item.Subtitle(fmt.Sprintf("Please Enter if roll back to this snapshot from %s", s.SourceURL)).
+				Arg(fmt.Sprintf("--%s --%s %s", rollbackFlag, confirmFlag, s.ID))
+		}
+		c.Append(item)
+	}
+	c.Variable(nextActionKey, nextActionShell).
+		Output()
+	return nil
+}
+
+func installDB(c *client, args []string) error {
+	// Note a path containing spaces is split into args
+	src, err := installSource(strings.Join(args, " "))
+	if c.cfg.confirm {
+		if err != nil {
+			return printUpdateResults(c.OutWriter(), err)
+		}
+		c.Logger().Infoln("installing tldr database from", src)
+		ctx, cancel := context.WithTimeout(context.Background(), updateDBTimeout)
+		defer cancel()
+		res, err := c.tldrClient.Install(ctx, src)
+		return printDBUpdateResults(c.OutWriter(), res, err)
+	}
+
+	if err != nil {
+		c.SetEmptyWarning(
+			"Please input a path to tldr.zip",
+			err.Error(),
+		).Output()
+		return nil
+	}
+	c.Append(
+		alfred.NewItem().
+			Title("Please Enter if install tldr database from the file").
+			Subtitle(src).
+			// Note a file url does not contain spaces
+			Arg(fmt.Sprintf("--%s --%s %s", installFlag, confirmFlag, src)),
+	).
+		Variable(nextActionKey, nextActionShell).
+		Output()
+
+	return nil
+}
+
+// installSource returns a file url of the zip at `arg` which is a path or a file url
+func installSource(arg string) (string, error) {
+	path := strings.TrimSpace(arg)
+	if strings.HasPrefix(path, "file://") {
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", err
+		}
+		path = u.Path
+	}
+	if path == "" {
+		return "", errors.New("no file specified")
+	}
+
+	path, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		return "", err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("not a file: %s", path)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
+
+func printPruneResults(w io.Writer, removed []string, err error) (_ error) {
+	switch {
+	case err != nil:
+		fmt.Fprintf(w, "prune failed due to %s", err)
+	case len(removed) == 0:
+		fmt.Fprintf(w, "prune succeeded, no languages to remove")
+	default:
+		fmt.Fprintf(w, "prune succeeded, removed %s", strings.Join(removed, ", "))
+	}
+	return
+}
+
+func pruneDB(c *client) error {
+	keep := c.cfg.fromEnv.keepLanguages
+	if c.cfg.confirm {
+		c.Logger().Infoln("removing unused languages from tldr database...")
+		removed, err := c.tldrClient.PruneLanguages(keep...)
+		return printPruneResults(c.OutWriter(), removed, err)
+	}
+
+	usages, err := c.tldrClient.DiskUsage()
+	if err != nil {
+		return err
+	}
+	targets, err := c.tldrClient.PruneTargets(keep...)
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		c.Append(
+			alfred.NewItem().
+				Title("No languages to remove").
+				Subtitle(fmt.Sprintf("languages not in %s are removed", envKeyDBKeepLanguages)).
+				Valid(false),
+		)
+	} else {
+		c.Append(
+			alfred.NewItem().
+				Title(fmt.Sprintf("Please Enter if remove %d languages", len(targets))).
+				Subtitle(strings.Join(targets, ", ")).
+				Arg(fmt.Sprintf("--%s --%s", pruneFlag, confirmFlag)),
+		)
+	}
+
+	removing := make(map[string]bool, len(targets))
+	for _, lang := range targets {
+		removing[lang] = true
+	}
+	var langs []string
+	byLang := make(map[string][]tldr.Usage)
+	for _, u := range usages {
+		if _, ok := byLang[u.Language]; !ok {
+			langs = append(langs, u.Language)
+		}
+		byLang[u.Language] = append(byLang[u.Language], u)
+	}
+	for _, lang := range langs {
+		var pages int
+		var size int64
+		details := make([]string, 0, len(byLang[lang]))
+		for _, u := range byLang[lang] {
+			pages += u.Pages
+			size += u.Bytes
+			details = append(details, fmt.Sprintf("%s: %d bytes", u.Platform, u.Bytes))
+		}
+		title := fmt.Sprintf("%d pages in %s, %d bytes", pages, lang, size)
+		if removing[lang] {
+			title += " (remove)"
+		}
+		c.Append(
+			alfred.NewItem().
+				Title(title).
+				Subtitle(strings.Join(details, ", ")).
+				Valid(false),
+		)
+	}
+	c.Variable(nextActionKey, nextActionShell).
+		Output()
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	workflowUpdateCheckJobName = "tldr-workflow-update-check"
+	workflowUpdateCheckFile    = "workflow-update-check.json"
+)
+
+// spawnWorkflowUpdateCheck starts a detached process to check a newer workflow. It is a variable for tests
+var spawnWorkflowUpdateCheck = func(c *client) error {
+	return startJob(c, workflowUpdateCheckJobName, "--"+checkWorkflowFlag)
+}
+
+// workflowUpdateCheck is the cached result of the workflow update check
+type workflowUpdateCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// Version is the workflow version at the check
+	Version   string `json:"version"`
+	Available bool   `json:"available"`
+}
+
+func (w *workflowUpdateCheck) expired(now time.Time, interval time.Duration) bool {
+	return w.Version != version || now.Sub(w.CheckedAt) > interval
+}
+
+func workflowUpdateCheckPath(c *client) string {
+	return filepath.Join(c.GetCacheDir(), workflowUpdateCheckFile)
+}
+
+func loadWorkflowUpdateCheck(path string) *workflowUpdateCheck {
+	w := &workflowUpdateCheck{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return w
+	}
+	if err := json.Unmarshal(b, w); err != nil {
+		return &workflowUpdateCheck{}
+	}
+	return w
+}
+
+// isNewerWorkflowAvailable returns the cached result without network access.
+// If the result is expired, the check runs in background and the result is used from the next query
+func isNewerWorkflowAvailable(c *client) bool {
+	path := workflowUpdateCheckPath(c)
+	w := loadWorkflowUpdateCheck(path)
+	if !w.expired(time.Now(), getUpdateWorkflowInterval(twoWeeks)) {
+		return w.Available
+	}
+
+	if !c.Job(workflowUpdateCheckJobName).IsRunning() {
+		if err := spawnWorkflowUpdateCheck(c); err != nil {
+			c.Logger().Warnln("failed to start the workflow update check", err)
+		}
+	}
+	// Note the check may have already finished
+	return loadWorkflowUpdateCheck(path).Available
+}
+
+// checkWorkflowUpdate checks a newer workflow and caches the result
+func checkWorkflowUpdate(c *client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), updateWorkflowCheckTimeout)
+	defer cancel()
+	w := &workflowUpdateCheck{
+		CheckedAt: time.Now(),
+		Version:   version,
+		Available: c.Updater().IsNewVersionAvailable(ctx),
+	}
+
+	b, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	path := workflowUpdateCheckPath(c)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/konoui/go-alfred"
+	"github.com/konoui/go-alfred/env"
+)
+
+func init() {
+	// Note tests check a newer workflow synchronously instead of spawning a process
+	spawnWorkflowUpdateCheck = checkWorkflowUpdate
+}
+
+func Test_workflowUpdateCheckExpired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		check workflowUpdateCheck
+		want  bool
+	}{
+		{
+			name:  "no cache",
+			check: workflowUpdateCheck{},
+			want:  true,
+		},
+		{
+			name:  "fresh cache",
+			check: workflowUpdateCheck{CheckedAt: now.Add(-time.Hour), Version: version},
+			want:  false,
+		},
+		{
+			name:  "old cache",
+			check: workflowUpdateCheck{CheckedAt: now.Add(-2 * time.Hour), Version: version},
+			want:  true,
+		},
+		{
+			name:  "cache of another version",
+			check: workflowUpdateCheck{CheckedAt: now, Version: "v0.0.1"},
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check.expired(now, 90*time.Minute); got != tt.want {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorkflowUpdateCheckCache(t *testing.T) {
+	const recommendation = "Newer tldr wrokflow is available!"
+	t.Setenv(envKeyUpdateWorkflowRecommendation, "true")
+	orgSpawn, orgTTL := spawnWorkflowUpdateCheck, twoWeeks
+	t.Cleanup(func() { spawnWorkflowUpdateCheck, twoWeeks = orgSpawn, orgTTL })
+	twoWeeks = 2 * 7 * 24 * time.Hour
+	spawned := 0
+	spawnWorkflowUpdateCheck = func(c *client) error {
+		spawned++
+		return nil
+	}
+	writeCache := func(w *workflowUpdateCheck) {
+		t.Helper()
+		b, err := json.Marshal(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(os.Getenv(env.KeyWorkflowCache), workflowUpdateCheckFile)
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		cache       *workflowUpdateCheck
+		want        bool
+		wantSpawned int
+	}{
+		{
+			name:        "no cache starts the check in background",
+			wantSpawned: 1,
+		},
+		{
+			name:  "fresh cache is used without the check",
+			cache: &workflowUpdateCheck{CheckedAt: time.Now(), Version: version, Available: true},
+			want:  true,
+		},
+		{
+			name:        "expired cache is used until the check finishes",
+			cache:       &workflowUpdateCheck{CheckedAt: time.Now().Add(-365 * 24 * time.Hour), Version: version, Available: true},
+			want:        true,
+			wantSpawned: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawned = 0
+			// Note the updater must not be called from queries
+			mockSource, teardown := newMockUpdaterSource(t, !tt.want)
+			defer teardown()
+			awf, cmd, outBuf, _ := setup(t, "lsof", alfred.WithUpdater(mockSource))
+			if tt.cache != nil {
+				writeCache(tt.cache)
+			}
+			execute(t, awf, cmd, 0)
+			if got := strings.Contains(outBuf.String(), recommendation); got != tt.want {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+			if spawned != tt.wantSpawned {
+				t.Errorf("want: %d spawns, got: %d", tt.wantSpawned, spawned)
+			}
+		})
+	}
+
+	t.Run("check execution caches the result", func(t *testing.T) {
+		mockSource, teardown := newMockUpdaterSource(t, true)
+		defer teardown()
+		awf, cmd, _, _ := setup(t, "--"+checkWorkflowFlag, alfred.WithUpdater(mockSource))
+		execute(t, awf, cmd, 0)
+		w := loadWorkflowUpdateCheck(filepath.Join(os.Getenv(env.KeyWorkflowCache), workflowUpdateCheckFile))
+		if !w.Available || w.Version != version || w.CheckedAt.IsZero() {
+			t.Errorf("unexpected cache: %+v", w)
+		}
+	})
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/konoui/alfred-tldr/pkg/tldr"
 	"github.com/konoui/go-alfred"
 )
 
@@ -22,6 +27,21 @@ const (
 	envKeyUpdateWorkflowIntervalDays   = "TLDR_WORKFLOW_UPDATE_INTERVAL_DAYS"
 	envKeyCommandFormat                = "TLDR_COMMAND_FORMAT"
 	envKeyOpenURLMod                   = "TLDR_MOD_KEY_OPEN_URL"
+	envKeyRepositoryURLs               = "TLDR_REPOSITORY_URLS"
+	envKeyHTTPProxy                    = "TLDR_HTTP_PROXY"
+	envKeyCAFile                       = "TLDR_CA_FILE"
+	envKeyHTTPHeaders                  = "TLDR_HTTP_HEADERS"
+	envKeyDBChecksum                   = "TLDR_DB_SHA256"
+	envKeyDBChecksumVerification       = "TLDR_DB_VERIFY_CHECKSUM"
+	envKeyDBPublicKey                  = "TLDR_DB_PUBLIC_KEY"
+	envKeyDBStorage                    = "TLDR_DB_STORAGE"
+	envKeyCustomPagesDirs              = "TLDR_CUSTOM_PAGES_DIRS"
+	envKeyLocalRepository              = "TLDR_LOCAL_REPOSITORY"
+	envKeyDBSnapshots                  = "TLDR_DB_SNAPSHOTS"
+	envKeyDBAutoUpdate                 = "TLDR_DB_AUTO_UPDATE"
+	envKeyDBLanguageArchives           = "TLDR_DB_LANGUAGE_ARCHIVES"
+	envKeyDBKeepLanguages              = "TLDR_DB_KEEP_LANGUAGES"
+	envKeyDBReleaseTag                 = "TLDR_DB_RELEASE_TAG"
 )
 
 func getModKeyOpenURL() alfred.ModKey {
@@ -75,6 +95,10 @@ func getCommandFormatFunc() func(string) string {
 	}
 }
 
+func isZipStorageEnabled() bool {
+	return os.Getenv(envKeyDBStorage) == "zip"
+}
+
 func isUpdateDBRecommendEnabled() bool {
 	return parseBool(envKeyUpdateDBRecommendation)
 }
@@ -93,6 +117,105 @@ func getUpdateWorkflowInterval(defaultInterval time.Duration) time.Duration {
 	return tv
 }
 
+// getDBSnapshots returns the number of databases to keep. 0 means the default
+func getDBSnapshots() int {
+	n, err := strconv.Atoi(os.Getenv(envKeyDBSnapshots))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// getLanguageArchiveURL returns the url template of per-language archives or empty if disabled
+func getLanguageArchiveURL() string {
+	v := strings.TrimSpace(os.Getenv(envKeyDBLanguageArchives))
+	if v == "" {
+		return ""
+	}
+	if ok, err := strconv.ParseBool(v); err == nil {
+		if ok {
+			return tldr.LanguageArchiveURL
+		}
+		return ""
+	}
+	return v
+}
+
+// getReleaseTag returns a tldr-pages release tag to pin the database to
+func getReleaseTag() string {
+	return strings.TrimSpace(os.Getenv(envKeyDBReleaseTag))
+}
+
+// getKeepLanguages returns language codes separated by commas or spaces
+func getKeepLanguages() []string {
+	v := os.Getenv(envKeyDBKeepLanguages)
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// getRepositoryURLs returns mirror urls separated by commas or spaces
+func getRepositoryURLs() []string {
+	v := os.Getenv(envKeyRepositoryURLs)
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// getCustomPagesDirs returns directories separated by colons. A leading `~` is expanded to the home dir
+func getCustomPagesDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(envKeyCustomPagesDirs)) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		dirs = append(dirs, expandHome(dir))
+	}
+	return dirs
+}
+
+// getLocalRepository returns the path of a local clone of tldr-pages
+func getLocalRepository() string {
+	dir := strings.TrimSpace(os.Getenv(envKeyLocalRepository))
+	if dir == "" {
+		return ""
+	}
+	return expandHome(dir)
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// parseHTTPHeaders parses headers scoped to hosts like `host1=Key1: Value1; host2=Key2: Value2`
+func parseHTTPHeaders(v string) (map[string]http.Header, error) {
+	headers := make(map[string]http.Header)
+	for _, kv := range strings.Split(v, ";") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		host, header, scoped := strings.Cut(kv, "=")
+		host = strings.TrimSpace(host)
+		key, value, found := strings.Cut(header, ":")
+		key = strings.TrimSpace(key)
+		// Note a header without a host is rejected not to send credentials to any host
+		if !scoped || !found || host == "" || key == "" || strings.ContainsAny(host, " /") {
+			return nil, fmt.Errorf("invalid header format %q in %s, `host=Key: Value` is expected", kv, envKeyHTTPHeaders)
+		}
+		if headers[host] == nil {
+			headers[host] = make(http.Header)
+		}
+		headers[host].Add(key, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
 func parseBool(key string) bool {
 	sv := os.Getenv(key)
 	bv, err := strconv.ParseBool(sv)
@@ -0,0 +1,151 @@
+package tldr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const changesFileName = "changes.json"
+
+// PageChange is a page added, changed or removed by an update
+type PageChange struct {
+	Path     string   `json:"path"`
+	Command  string   `json:"command"`
+	Platform Platform `json:"platform"`
+	Language string   `json:"language"`
+}
+
+// ChangeReport is pages added, changed and removed by the last update
+type ChangeReport struct {
+	UpdatedAt time.Time    `json:"updated_at"`
+	SourceURL string       `json:"source_url"`
+	Added     []PageChange `json:"added"`
+	Changed   []PageChange `json:"changed"`
+	Removed   []PageChange `json:"removed"`
+}
+
+// WhatsNew returns the report of the last update which installed a new database.
+// fs.ErrNotExist is returned if no database has been updated since the first one
+func (t *Tldr) WhatsNew() (*ChangeReport, error) {
+	f, err := os.Open(filepath.Join(t.path, changesFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a change report: %w", err)
+	}
+	defer f.Close()
+
+	r := &ChangeReport{}
+	if err := json.NewDecoder(f).Decode(r); err != nil {
+		return nil, fmt.Errorf("failed to parse a change report: %w", err)
+	}
+	return r, nil
+}
+
+// pageDiff is paths of pages which differ between two databases
+type pageDiff struct {
+	added   []string
+	changed []string
+	removed []string
+}
+
+func (d *pageDiff) result(sourceURL string) *UpdateResult {
+	return &UpdateResult{
+		SourceURL:    sourceURL,
+		AddedPages:   d.added,
+		ChangedPages: d.changed,
+		RemovedPages: d.removed,
+	}
+}
+
+// diffPages compares pages of the current database with pages of `names` in `fsys` by content hash.
+// It returns false if there is no current database to compare
+func (t *Tldr) diffPages(fsys fs.FS, names []string) (*pageDiff, bool) {
+	if t.useFallback || !t.hasDB() {
+		return &pageDiff{}, false
+	}
+	current, err := t.storage()
+	if err != nil {
+		return &pageDiff{}, false
+	}
+	currentNames, err := listPages(current)
+	if err != nil {
+		return &pageDiff{}, false
+	}
+
+	exists := make(map[string]bool, len(currentNames))
+	for _, name := range currentNames {
+		exists[name] = true
+	}
+	diff := &pageDiff{}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, _, _, ok := parsePagePath(name); !ok {
+			continue
+		}
+		seen[name] = true
+		if !exists[name] {
+			diff.added = append(diff.added, name)
+			continue
+		}
+		newHash, err := hashFile(fsys, name)
+		if err != nil {
+			continue
+		}
+		oldHash, err := hashFile(current, name)
+		if err != nil {
+			continue
+		}
+		if newHash != oldHash {
+			diff.changed = append(diff.changed, name)
+		}
+	}
+	for _, name := range currentNames {
+		if _, _, _, ok := parsePagePath(name); ok && !seen[name] {
+			diff.removed = append(diff.removed, name)
+		}
+	}
+	return diff, true
+}
+
+// saveChanges saves `diff` as the report of the last update
+func (t *Tldr) saveChanges(diff *pageDiff, sourceURL string) error {
+	return writeJSON(filepath.Join(t.path, changesFileName), &ChangeReport{
+		UpdatedAt: time.Now(),
+		SourceURL: sourceURL,
+		Added:     pageChanges(diff.added),
+		Changed:   pageChanges(diff.changed),
+		Removed:   pageChanges(diff.removed),
+	})
+}
+
+func pageChanges(names []string) []PageChange {
+	changes := make([]PageChange, 0, len(names))
+	for _, name := range names {
+		lang, pt, cmd, ok := parsePagePath(name)
+		if !ok {
+			continue
+		}
+		changes = append(changes, PageChange{Path: name, Command: cmd, Platform: pt, Language: lang})
+	}
+	return changes
+}
+
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
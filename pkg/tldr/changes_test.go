@@ -0,0 +1,80 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWhatsNew(t *testing.T) {
+	oldZip := writeTestZip(t, []zipEntry{
+		{name: indexFileName, body: testIndex},
+		{name: "pages/common/tar.md", body: "# tar"},
+		{name: "pages/common/old-name.md", body: "# old-name"},
+		{name: "pages.ja/linux/ls.md", body: "# ls"},
+	})
+	newZip := writeTestZip(t, []zipEntry{
+		{name: indexFileName, body: testIndex},
+		{name: "pages/common/tar.md", body: "# tar\n\n> new description"},
+		{name: "pages/common/new-name.md", body: "# new-name"},
+		{name: "pages.ja/linux/ls.md", body: "# ls"},
+	})
+
+	for _, mode := range storageModes {
+		t.Run(mode.name, func(t *testing.T) {
+			srv := newSwappableZipServer(t)
+			srv.serve(oldZip)
+
+			opts := append([]Option{WithRepositoryURL(srv.URL)}, mode.opts...)
+			tldr := New(filepath.Join(t.TempDir(), ".tldr"), opts...)
+			t.Cleanup(func() { tldr.Close() })
+			res, err := tldr.Update(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.AddedPages) != 0 {
+				t.Errorf("the first update should not report all pages: %v", res.AddedPages)
+			}
+			if _, err := tldr.WhatsNew(); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("want: %v, got: %v", fs.ErrNotExist, err)
+			}
+
+			srv.serve(newZip)
+			res, err = tldr.Update(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{"pages/common/new-name.md"}, res.AddedPages); diff != "" {
+				t.Errorf("added -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff([]string{"pages/common/tar.md"}, res.ChangedPages); diff != "" {
+				t.Errorf("changed -want +got\n%s", diff)
+			}
+			if diff := cmp.Diff([]string{"pages/common/old-name.md"}, res.RemovedPages); diff != "" {
+				t.Errorf("removed -want +got\n%s", diff)
+			}
+
+			report, err := tldr.WhatsNew()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &ChangeReport{
+				SourceURL: srv.URL,
+				Added:     []PageChange{{Path: "pages/common/new-name.md", Command: "new-name", Platform: PlatformCommon, Language: "en"}},
+				Changed:   []PageChange{{Path: "pages/common/tar.md", Command: "tar", Platform: PlatformCommon, Language: "en"}},
+				Removed:   []PageChange{{Path: "pages/common/old-name.md", Command: "old-name", Platform: PlatformCommon, Language: "en"}},
+			}
+			if report.UpdatedAt.IsZero() {
+				t.Errorf("no update time")
+			}
+			report.UpdatedAt = want.UpdatedAt
+			if diff := cmp.Diff(want, report); diff != "" {
+				t.Errorf("-want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,20 +3,301 @@ package tldr
 import (
 	"archive/zip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 )
 
-// download data from `url` to `dstDir` as `filename`
-func download(ctx context.Context, url, dstDir, filename string) (_ string, reterr error) {
+var errNotModified = errors.New("not modified")
+
+// RetryPolicy configures retries of downloading for transient errors
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first request
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy is used if no policy is specified
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 1 * time.Second,
+	MaxBackoff:     8 * time.Second,
+}
+
+// backoff return the wait time before the next attempt of `attempt`
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// statusError is returned when the response code is unexpected
+type statusError struct {
+	code int
+	url  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("http response code was %d for downloading from %s", e.code, e.url)
+}
+
+// isRetryable return true if `err` may be resolved by retrying
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+
+	// Note errors of a local file are not resolved by retrying
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return false
+	}
+
+	// Note every *url.Error is a net.Error, so only timeouts are retried
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isNotFound return true if the file of `err` does not exist
+func isNotFound(err error) bool {
+	var se *statusError
+	return (errors.As(err, &se) && se.code == http.StatusNotFound) || errors.Is(err, fs.ErrNotExist)
+}
+
+// downloader downloads files over http
+type downloader struct {
+	client  *http.Client
+	headers map[string]http.Header
+	policy  RetryPolicy
+}
+
+func newDownloader() *downloader {
+	return &downloader{
+		client:  &http.Client{},
+		headers: make(map[string]http.Header),
+		policy:  DefaultRetryPolicy,
+	}
+}
+
+// retry calls `fn` until it succeeds or a non-retryable error happens
+func (d *downloader) retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= d.policy.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(d.policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s: %w", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// downloadWithRetry calls download with retries
+func (d *downloader) downloadWithRetry(ctx context.Context, url, dstDir, filename string, cond *validators) (*archive, error) {
+	var a *archive
+	err := d.retry(ctx, func() (err error) {
+		a, err = d.download(ctx, url, dstDir, filename, cond)
+		return
+	})
+	return a, err
+}
+
+// validators of a downloaded file used for conditional requests
+type validators struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// archive is a downloaded file
+type archive struct {
+	path   string
+	size   int64
+	sha256 string
+	validators
+}
+
+// headerTransport adds configured headers to requests to their hosts.
+// Note headers are added per request not to be carried over by redirects to another host
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]http.Header
+}
+
+func (ht *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header, ok := ht.headers[strings.ToLower(req.URL.Host)]
+	if !ok {
+		header = ht.headers[strings.ToLower(req.URL.Hostname())]
+	}
+	if len(header) == 0 {
+		return ht.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return ht.base.RoundTrip(req)
+}
+
+// httpClient returns the client which sends configured headers
+func (d *downloader) httpClient() *http.Client {
+	if len(d.headers) == 0 {
+		return d.client
+	}
+	base := d.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := *d.client
+	c.Transport = &headerTransport{base: base, headers: d.headers}
+	return &c
+}
+
+// get sends a GET request with configured headers. The caller must close the body
+func (d *downloader) get(ctx context.Context, url string, cond *validators) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if cond != nil && cond.URL == url {
+		if cond.ETag != "" {
+			req.Header.Set("If-None-Match", cond.ETag)
+		}
+		if cond.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cond.LastModified)
+		}
+	}
+
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cond != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, errNotModified
+	}
+	if code := resp.StatusCode; code != http.StatusOK {
+		resp.Body.Close()
+		return nil, &statusError{code: code, url: url}
+	}
+	return resp, nil
+}
+
+// open returns the body of `url` and its validators.
+// `url` is a http(s) url, a file url or a local path.
+// The caller must close the body
+func (d *downloader) open(ctx context.Context, url string, cond *validators) (io.ReadCloser, *validators, error) {
+	if p, ok := localPath(url); ok {
+		return openLocal(p, url, cond)
+	}
+
+	resp, err := d.get(ctx, url, cond)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, &validators{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// openLocal opens the file at `path`. The modification time is used as the validator
+func openLocal(path, url string, cond *validators) (io.ReadCloser, *validators, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		f.Close()
+		return nil, nil, fmt.Errorf("not a regular file: %s", path)
+	}
+
+	v := &validators{
+		URL:          url,
+		LastModified: fi.ModTime().UTC().Format(http.TimeFormat),
+	}
+	if cond != nil && cond.URL == v.URL && cond.LastModified == v.LastModified {
+		f.Close()
+		return nil, nil, errNotModified
+	}
+	return f, v, nil
+}
+
+// localPath returns the file path if `u` is a file url or a path without a scheme
+func localPath(u string) (string, bool) {
+	if strings.HasPrefix(u, "file://") {
+		if parsed, err := neturl.Parse(u); err == nil {
+			return filepath.FromSlash(parsed.Path), true
+		}
+		return strings.TrimPrefix(u, "file://"), true
+	}
+	if strings.Contains(u, "://") {
+		return "", false
+	}
+	return u, true
+}
+
+// download data from `url` to `dstDir` as `filename`.
+// If `cond` is given and the remote file is not modified, errNotModified is returned
+func (d *downloader) download(ctx context.Context, url, dstDir, filename string, cond *validators) (_ *archive, reterr error) {
+	body, v, err := d.open(ctx, url, cond)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
 	path := filepath.Join(dstDir, filename)
+	if src, ok := body.(*os.File); ok {
+		// Note creating the destination truncates the source if they are the same file
+		if sfi, err := src.Stat(); err == nil {
+			if dfi, err := os.Stat(path); err == nil && os.SameFile(sfi, dfi) {
+				return nil, fmt.Errorf("cannot install from the data directory: %s", url)
+			}
+		}
+	}
 	f, err := os.Create(path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer func() {
 		if ferr := f.Close(); ferr != nil && reterr == nil {
@@ -24,31 +305,78 @@ func download(ctx context.Context, url, dstDir, filename string) (_ string, rete
 		}
 	}()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	return &archive{
+		path:       path,
+		size:       size,
+		sha256:     hex.EncodeToString(h.Sum(nil)),
+		validators: *v,
+	}, nil
+}
 
-	if code := resp.StatusCode; code != http.StatusOK {
-		return "", fmt.Errorf("http response code was %d for downloading from %s", code, url)
-	}
+// maxSidecarSize is the limit of small files such as a checksum
+const maxSidecarSize = 64 << 10
 
-	if _, err := io.Copy(f, resp.Body); err != nil {
-		return "", err
-	}
+// fetch returns the body of `url` which is expected to be small
+func (d *downloader) fetch(ctx context.Context, url string) ([]byte, error) {
+	var data []byte
+	err := d.retry(ctx, func() error {
+		body, _, err := d.open(ctx, url, nil)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
 
-	return path, nil
+		data, err = io.ReadAll(io.LimitReader(body, maxSidecarSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxSidecarSize {
+			return fmt.Errorf("too large response from %s", url)
+		}
+		return nil
+	})
+	return data, err
+}
+
+// limits for extracting a zip
+const (
+	maxEntrySize   = 16 << 20 // 16MiB
+	maxArchiveSize = 2 << 30  // 2GiB
+	maxEntries     = 500000
+)
+
+var (
+	ErrUnsafePath       = errors.New("unsafe path in a zip")
+	ErrUnsupportedEntry = errors.New("unsupported entry in a zip")
+	ErrEntryTooLarge    = errors.New("too large entry in a zip")
+	ErrArchiveTooLarge  = errors.New("too large extracted size of a zip")
+	ErrTooManyEntries   = errors.New("too many entries in a zip")
+)
+
+var defaultExtractLimits = extractLimits{
+	entrySize:   maxEntrySize,
+	archiveSize: maxArchiveSize,
+	entries:     maxEntries,
+}
+
+type extractLimits struct {
+	entrySize   int64
+	archiveSize int64
+	entries     int
 }
 
 // unzip to `dstDir`
 func unzip(ctx context.Context, zipPath, dstDir string) error {
+	return unzipWithLimits(ctx, zipPath, dstDir, defaultExtractLimits)
+}
+
+func unzipWithLimits(ctx context.Context, zipPath, dstDir string, limits extractLimits) error {
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return err
@@ -58,46 +386,94 @@ func unzip(ctx context.Context, zipPath, dstDir string) error {
 	if len(r.File) == 0 {
 		return errors.New("no files in a zip")
 	}
+	if len(r.File) > limits.entries {
+		return fmt.Errorf("%w: %d entries", ErrTooManyEntries, len(r.File))
+	}
 
+	var total int64
 	for _, f := range r.File {
-		fn := func() error {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-				break
-			}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		dst, err := entryPath(dstDir, f.Name)
+		if err != nil {
+			return err
+		}
 
-			rc, err := f.Open()
-			if err != nil {
+		mode := f.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(dst, 0o755); err != nil {
 				return err
 			}
-			defer rc.Close()
-
-			if f.FileInfo().IsDir() {
-				path := filepath.Join(dstDir, f.Name)
-				if err := os.MkdirAll(path, f.Mode()); err != nil {
-					return err
-				}
-			} else {
-				buf := make([]byte, f.UncompressedSize)
-				_, err := io.ReadFull(rc, buf)
-				if err != nil {
-					return err
-				}
-
-				path := filepath.Join(dstDir, f.Name)
-				if err := os.WriteFile(path, buf, f.Mode()); err != nil {
-					return err
-				}
-			}
-			return nil
+			continue
+		}
+		if !mode.IsRegular() {
+			return fmt.Errorf("%w: %s (%s)", ErrUnsupportedEntry, f.Name, mode.Type())
+		}
+		if f.UncompressedSize64 > uint64(limits.entrySize) {
+			return fmt.Errorf("%w: %s", ErrEntryTooLarge, f.Name)
 		}
 
-		if reterr := fn(); reterr != nil {
-			return reterr
+		n, err := extractFile(f, dst, limits.entrySize)
+		if err != nil {
+			return err
+		}
+		total += n
+		if total > limits.archiveSize {
+			return fmt.Errorf("%w: exceeds %d bytes", ErrArchiveTooLarge, limits.archiveSize)
 		}
 	}
 
 	return nil
 }
+
+// extractFile streams `f` to `dst` and returns written bytes
+func extractFile(f *zip.File, dst string, limit int64) (_ int64, reterr error) {
+	// Note a zip does not always contain directory entries
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return 0, err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if werr := w.Close(); werr != nil && reterr == nil {
+			reterr = werr
+		}
+	}()
+
+	// Note the header size can lie, limit actual bytes
+	n, err := io.Copy(w, io.LimitReader(rc, limit+1))
+	if err != nil {
+		return n, err
+	}
+	if n > limit {
+		return n, fmt.Errorf("%w: %s", ErrEntryTooLarge, f.Name)
+	}
+	return n, nil
+}
+
+// entryPath returns a path of `name` under `dstDir` or an error if `name` escapes from `dstDir`
+func entryPath(dstDir, name string) (string, error) {
+	if name == "" || strings.Contains(name, `\`) || strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	return filepath.Join(dstDir, filepath.FromSlash(cleaned)), nil
+}
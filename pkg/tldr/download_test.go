@@ -1,10 +1,20 @@
 package tldr
 
 import (
+	"archive/zip"
 	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDownload(t *testing.T) {
@@ -24,13 +34,13 @@ func TestDownload(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// since download on current directory, got is only filename
-			got, err := download(context.TODO(), tt.url, "", tt.want)
-			t.Cleanup(func() { os.RemoveAll(got) })
+			a, err := newDownloader().download(context.TODO(), tt.url, "", tt.want, nil)
 			if !tt.expectErr && err != nil {
-				t.Errorf("unexpected error got: %+v", err)
+				t.Fatalf("unexpected error got: %+v", err)
 			}
+			t.Cleanup(func() { os.RemoveAll(a.path) })
 
-			if tt.want != got {
+			if got := a.path; tt.want != got {
 				t.Errorf("want: %+v, got: %+v", tt.want, got)
 			}
 		})
@@ -52,15 +62,343 @@ func TestUnzip(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tmpDir := t.TempDir()
-			path, err := download(context.TODO(), tt.url, tmpDir, filepath.Base(tt.url))
+			a, err := newDownloader().download(context.TODO(), tt.url, tmpDir, filepath.Base(tt.url), nil)
 			if err != nil {
 				t.Fatalf("faltal error: %+v", err)
 			}
-			defer os.RemoveAll(path)
+			defer os.RemoveAll(a.path)
+
+			if err := unzip(context.TODO(), a.path, tmpDir); !tt.expectErr && err != nil {
+				t.Errorf("unexpected error got: %+v", err)
+			}
+		})
+	}
+}
+
+// storageModes are options to run a test in each storage mode
+var storageModes = []struct {
+	name string
+	opts []Option
+}{
+	{name: "directory"},
+	{name: "zip", opts: []Option{WithZipStorage()}},
+}
+
+// swappableZipServer serves a zip which a test can swap between updates
+type swappableZipServer struct {
+	URL     string
+	mu      sync.Mutex
+	zipPath string
+}
+
+func newSwappableZipServer(t *testing.T) *swappableZipServer {
+	t.Helper()
+	s := &swappableZipServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		zipPath := s.zipPath
+		s.mu.Unlock()
+		// Note no validators not to respond 304
+		b, err := os.ReadFile(zipPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL + "/tldr.zip"
+	return s
+}
+
+// serve changes the zip to serve to `zipPath`
+func (s *swappableZipServer) serve(zipPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zipPath = zipPath
+}
+
+type zipEntry struct {
+	name string
+	body string
+	mode os.FileMode
+}
+
+func writeTestZip(t *testing.T, entries []zipEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			fh.SetMode(e.mode)
+		}
+		fw, err := w.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnzipRejection(t *testing.T) {
+	limits := extractLimits{
+		entrySize:   8,
+		archiveSize: 12,
+		entries:     3,
+	}
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		wantErr error
+	}{
+		{
+			name:    "path traversal",
+			entries: []zipEntry{{name: "../evil.md", body: "a"}},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "nested path traversal",
+			entries: []zipEntry{{name: "pages/../../evil.md", body: "a"}},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "absolute path",
+			entries: []zipEntry{{name: "/tmp/evil.md", body: "a"}},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "backslash path",
+			entries: []zipEntry{{name: `..\evil.md`, body: "a"}},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "symlink",
+			entries: []zipEntry{{name: "pages/link", body: "/etc/passwd", mode: os.ModeSymlink | 0o777}},
+			wantErr: ErrUnsupportedEntry,
+		},
+		{
+			name:    "too large entry",
+			entries: []zipEntry{{name: "pages/large.md", body: "123456789"}},
+			wantErr: ErrEntryTooLarge,
+		},
+		{
+			name: "too large archive",
+			entries: []zipEntry{
+				{name: "pages/a.md", body: "1234567"},
+				{name: "pages/b.md", body: "1234567"},
+			},
+			wantErr: ErrArchiveTooLarge,
+		},
+		{
+			name: "too many entries",
+			entries: []zipEntry{
+				{name: "a.md"}, {name: "b.md"}, {name: "c.md"}, {name: "d.md"},
+			},
+			wantErr: ErrTooManyEntries,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zipPath := writeTestZip(t, tt.entries)
+			dstDir := filepath.Join(t.TempDir(), "dst")
+			err := unzipWithLimits(context.TODO(), zipPath, dstDir, limits)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("want: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUnzipWithoutDirectoryEntries(t *testing.T) {
+	zipPath := writeTestZip(t, []zipEntry{
+		{name: "pages/common/a.md", body: "# a"},
+	})
+	dstDir := t.TempDir()
+	if err := unzip(context.TODO(), zipPath, dstDir); err != nil {
+		t.Fatalf("unexpected error got: %+v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dstDir, "pages", "common", "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# a" {
+		t.Errorf("want: # a, got: %s", got)
+	}
+}
+
+func TestDownloadNotModified(t *testing.T) {
+	tmpDir := t.TempDir()
+	url := testServer.TldrZipURL()
+	a, err := newDownloader().download(context.TODO(), url, tmpDir, filepath.Base(url), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := a.validators
+	if v.ETag == "" || v.LastModified == "" {
+		t.Fatalf("validators are empty: %+v", v)
+	}
+
+	tests := []struct {
+		name    string
+		cond    *validators
+		wantErr error
+	}{
+		{
+			name:    "etag matches",
+			cond:    &validators{URL: url, ETag: v.ETag},
+			wantErr: errNotModified,
+		},
+		{
+			name:    "last modified matches",
+			cond:    &validators{URL: url, LastModified: v.LastModified},
+			wantErr: errNotModified,
+		},
+		{
+			name:    "etag does not match",
+			cond:    &validators{URL: url, ETag: `"dummy"`},
+			wantErr: nil,
+		},
+		{
+			name:    "validators of other url are ignored",
+			cond:    &validators{URL: "http://example.com/tldr.zip", ETag: v.ETag},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newDownloader().download(context.TODO(), url, tmpDir, filepath.Base(url), tt.cond)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("want: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// newFlakyServer returns a server responding `code` for first `failures` requests then redirecting to the test zip
+func newFlakyServer(t *testing.T, failures int, code int) (*httptest.Server, *int32) {
+	t.Helper()
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := atomic.AddInt32(&count, 1); int(n) <= failures {
+			w.WriteHeader(code)
+			return
+		}
+		http.Redirect(w, r, testServer.TldrZipURL(), http.StatusFound)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &count
+}
 
-			if err := unzip(context.TODO(), path, tmpDir); !tt.expectErr && err != nil {
+func TestDownloadWithRetry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+	tests := []struct {
+		name         string
+		failures     int
+		code         int
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{
+			name:         "retry 5xx and succeed",
+			failures:     2,
+			code:         http.StatusServiceUnavailable,
+			wantErr:      false,
+			wantAttempts: 3,
+		},
+		{
+			name:         "give up after max attempts",
+			failures:     3,
+			code:         http.StatusBadGateway,
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:         "do not retry 4xx",
+			failures:     1,
+			code:         http.StatusNotFound,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, count := newFlakyServer(t, tt.failures, tt.code)
+			url := ts.URL + "/tldr.zip"
+			d := newDownloader()
+			d.policy = policy
+			_, err := d.downloadWithRetry(context.TODO(), url, t.TempDir(), "tldr.zip", nil)
+			if tt.wantErr && err == nil {
+				t.Errorf("expect error happens, but got response")
+			}
+			if !tt.wantErr && err != nil {
 				t.Errorf("unexpected error got: %+v", err)
 			}
+			if got := atomic.LoadInt32(count); got != tt.wantAttempts {
+				t.Errorf("attempts want: %d, got: %d", tt.wantAttempts, got)
+			}
 		})
 	}
 }
+
+func TestDownloadWithRetryTLSError(t *testing.T) {
+	var count int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+	ts.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt32(&count, 1)
+		}
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	d := newDownloader()
+	d.policy = RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+	// Note the default client does not trust the certificate of the test server
+	_, err := d.downloadWithRetry(context.TODO(), ts.URL+"/tldr.zip", t.TempDir(), "tldr.zip", nil)
+	if err == nil {
+		t.Fatal("expect error happens, but got response")
+	}
+	if got := atomic.LoadInt32(&count); got != 1 {
+		t.Errorf("attempts want: 1, got: %d", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+	for attempt, want := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 5 * time.Second,
+	} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("attempt %d want: %v, got: %v", attempt, want, got)
+		}
+	}
+}
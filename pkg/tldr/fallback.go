@@ -0,0 +1,29 @@
+package tldr
+
+import (
+	"io/fs"
+	"time"
+)
+
+// WithFallbackStorage uses `fsys` as a read-only database until the first update succeeds,
+// e.g.) a snapshot embedded in the binary for offline first-run.
+// OnInitialize does not download the first database if the fallback is available unless WithForceUpdate is specified.
+// `fsys` must have the layout of tldr.zip
+func WithFallbackStorage(fsys fs.FS) Option {
+	return func(t *Tldr) {
+		t.fallback = fsys
+	}
+}
+
+// Fallback returns true and the time of the fallback storage if it is in use instead of the database.
+// The time is the modification time of the index file in the storage
+func (t *Tldr) Fallback() (time.Time, bool) {
+	if !t.useFallback {
+		return time.Time{}, false
+	}
+	fi, err := fs.Stat(t.fallback, indexFileName)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return fi.ModTime(), true
+}
@@ -0,0 +1,79 @@
+package tldr
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFallbackStorage(t *testing.T) {
+	snapshotTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	fallback := fstest.MapFS{
+		indexFileName:          {Data: []byte(testIndex), ModTime: snapshotTime},
+		"pages/common/lsof.md": {Data: []byte("# bundled lsof\n")},
+	}
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+
+	t.Run("use the fallback without downloading the first database", func(t *testing.T) {
+		transport := &countingTransport{}
+		tldr := New(tldrPath, WithTestInvalidURL(), WithFallbackStorage(fallback),
+			WithHTTPClient(&http.Client{Transport: transport}))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt32(&transport.count); n != 0 {
+			t.Errorf("the database should not be downloaded on initialize: %d requests", n)
+		}
+		got, ok := tldr.Fallback()
+		if !ok || !got.Equal(snapshotTime) {
+			t.Errorf("want: %v, got: %v, %v", snapshotTime, got, ok)
+		}
+		page, err := tldr.FindPage([]string{"lsof"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.CmdName != "bundled lsof" {
+			t.Errorf("want: bundled lsof, got: %s", page.CmdName)
+		}
+		if !tldr.Expired(time.Hour) {
+			t.Errorf("the fallback should be expired to recommend an update")
+		}
+
+		// Note a update succeeds after the network is available
+		tldr.pageSourceURLs = []string{testServer.TldrZipURL()}
+		if _, err := tldr.Update(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := tldr.Fallback(); ok {
+			t.Errorf("the fallback is in use after the update")
+		}
+		page, err = tldr.FindPage([]string{"lsof"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.CmdName != "lsof" {
+			t.Errorf("want: lsof, got: %s", page.CmdName)
+		}
+	})
+
+	t.Run("not use the fallback if the database exists", func(t *testing.T) {
+		tldr := New(tldrPath, WithTestInvalidURL(), WithFallbackStorage(fallback))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := tldr.Fallback(); ok {
+			t.Errorf("the fallback is in use with the database")
+		}
+	})
+
+	t.Run("not use the fallback if the forced update failed", func(t *testing.T) {
+		tldr := New(tldrPath, WithTestInvalidURL(), WithFallbackStorage(fallback), WithForceUpdate())
+		if err := tldr.OnInitialize(context.TODO()); err == nil {
+			t.Errorf("expect error happens")
+		}
+	})
+}
@@ -2,8 +2,9 @@ package tldr
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 
 	"github.com/sahilm/fuzzy"
@@ -14,6 +15,64 @@ type CmdInfo struct {
 	Name      string     `json:"name"`
 	Platforms []Platform `json:"platform"`
 	Languages []string   `json:"language"`
+	// Targets are pairs of a platform and a language which have a page
+	Targets []Target `json:"targets,omitempty"`
+	// Custom is true if the command has pages in overlay dirs
+	Custom bool `json:"-"`
+}
+
+// Target is a pair of a platform and a language of a page
+type Target struct {
+	OS       Platform `json:"os"`
+	Language string   `json:"language"`
+}
+
+// UnmarshalJSON decodes both the legacy schema and the schema with targets.
+// Unknown fields of newer schemas are ignored
+func (c *CmdInfo) UnmarshalJSON(b []byte) error {
+	type cmdInfo CmdInfo
+	var v cmdInfo
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*c = CmdInfo(v)
+	c.normalize()
+	return nil
+}
+
+// normalize fills missing targets or legacy fields from the others
+func (c *CmdInfo) normalize() {
+	if len(c.Targets) == 0 {
+		// Note the legacy schema does not tell which pairs exist, assume all of them
+		for _, pt := range c.Platforms {
+			for _, lang := range c.Languages {
+				c.Targets = append(c.Targets, Target{OS: pt, Language: lang})
+			}
+		}
+		return
+	}
+	for _, tg := range c.Targets {
+		c.Platforms = appendPlatform(c.Platforms, tg.OS)
+		c.Languages = appendString(c.Languages, tg.Language)
+	}
+}
+
+// PlatformsIn returns platforms which have a page in one of `langs`.
+// All platforms are returned if `langs` is empty
+func (c *CmdInfo) PlatformsIn(langs ...string) []Platform {
+	if len(langs) == 0 {
+		return c.Platforms
+	}
+	var pts []Platform
+	for _, pt := range c.Platforms {
+		for _, tg := range c.Targets {
+			if tg.OS == pt && contains(tg.Language, langs) {
+				pts = append(pts, pt)
+				break
+			}
+		}
+	}
+	return pts
 }
 
 // Cmds a slice of CmdInfo
@@ -73,12 +132,58 @@ func guessHyphenCommand(args []string) bool {
 
 // LoadIndexFile load command index file
 func (t *Tldr) LoadIndexFile() (*CmdsIndex, error) {
-	f, err := os.Open(t.indexFilePath())
+	cmdIndex, err := t.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range t.overlays {
+		names, err := listPages(o)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list custom pages: %w", err)
+		}
+		custom := buildIndex(names)
+		for _, info := range custom.Commands {
+			info.Custom = true
+		}
+		mergeIndex(cmdIndex, custom)
+	}
+	return cmdIndex, nil
+}
+
+// loadIndex loads the index file of the page tree in use.
+// If a read-only storage such as a local repository does not have it, the index is built from the pages
+func (t *Tldr) loadIndex() (*CmdsIndex, error) {
+	if t.indexOutdated() {
+		// Note use the current index file while another process updates the database
+		if unlock, err := t.tryLock(); err == nil {
+			cmdIndex, err := t.reindex()
+			unlock()
+			if err == nil {
+				return cmdIndex, nil
+			}
+		}
+	}
+
+	f, err := t.openIndexFile()
+	if errors.Is(err, fs.ErrNotExist) && t.customStorage != nil {
+		names, err := listPages(t.customStorage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pages: %w", err)
+		}
+		return buildIndex(names), nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open a index file: %w", err)
 	}
 	defer f.Close()
 
 	cmdIndex := &CmdsIndex{}
-	return cmdIndex, json.NewDecoder(f).Decode(cmdIndex)
+	if err := json.NewDecoder(f).Decode(cmdIndex); err != nil {
+		return nil, fmt.Errorf("failed to parse a index file: %w", err)
+	}
+	return cmdIndex, nil
 }
@@ -2,9 +2,12 @@ package tldr
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestLoadIndexFile(t *testing.T) {
@@ -91,3 +94,96 @@ func TestSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestCmdInfoUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *CmdInfo
+	}{
+		{
+			name: "legacy schema",
+			data: `{"name":"tar","platform":["common","linux"],"language":["en","ja"]}`,
+			want: &CmdInfo{
+				Name: "tar", Platforms: []Platform{PlatformCommon, PlatformLinux}, Languages: []string{"en", "ja"},
+				Targets: []Target{
+					{OS: PlatformCommon, Language: "en"},
+					{OS: PlatformCommon, Language: "ja"},
+					{OS: PlatformLinux, Language: "en"},
+					{OS: PlatformLinux, Language: "ja"},
+				},
+			},
+		},
+		{
+			name: "schema with targets",
+			data: `{"name":"tar","platform":["common","linux"],"language":["en","ja"],` +
+				`"targets":[{"os":"common","language":"en"},{"os":"common","language":"ja"},{"os":"linux","language":"en"}]}`,
+			want: &CmdInfo{
+				Name: "tar", Platforms: []Platform{PlatformCommon, PlatformLinux}, Languages: []string{"en", "ja"},
+				Targets: []Target{
+					{OS: PlatformCommon, Language: "en"},
+					{OS: PlatformCommon, Language: "ja"},
+					{OS: PlatformLinux, Language: "en"},
+				},
+			},
+		},
+		{
+			name: "future schema only with targets and unknown fields",
+			data: `{"name":"tar","targets":[{"os":"linux","language":"en","path":"pages/linux/tar.md"}],"aliases":["gtar"]}`,
+			want: &CmdInfo{
+				Name: "tar", Platforms: []Platform{PlatformLinux}, Languages: []string{"en"},
+				Targets: []Target{{OS: PlatformLinux, Language: "en"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &CmdInfo{}
+			if err := json.Unmarshal([]byte(tt.data), got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("-want +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCmdInfoPlatformsIn(t *testing.T) {
+	info := &CmdInfo{
+		Name: "tar", Platforms: []Platform{PlatformCommon, PlatformLinux}, Languages: []string{"en", "ja"},
+		Targets: []Target{
+			{OS: PlatformCommon, Language: "en"},
+			{OS: PlatformLinux, Language: "en"},
+			{OS: PlatformLinux, Language: "ja"},
+		},
+	}
+	tests := []struct {
+		name  string
+		langs []string
+		want  []Platform
+	}{
+		{
+			name:  "all platforms without languages",
+			langs: nil,
+			want:  []Platform{PlatformCommon, PlatformLinux},
+		},
+		{
+			name:  "only platforms in the language",
+			langs: []string{"ja"},
+			want:  []Platform{PlatformLinux},
+		},
+		{
+			name:  "no platforms in the language",
+			langs: []string{"fr"},
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, info.PlatformsIn(tt.langs...)); diff != "" {
+				t.Errorf("-want +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Reindex rebuilds the index file from the page tree of the database.
+// In zip storage mode, the index file is placed next to the zip
+func (t *Tldr) Reindex() (*CmdsIndex, error) {
+	if t.customStorage != nil || t.useFallback {
+		return nil, ErrReadOnlyStorage
+	}
+
+	unlock, err := t.lock(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return t.reindex()
+}
+
+// reindex rebuilds the index file. The lock must be held
+func (t *Tldr) reindex() (*CmdsIndex, error) {
+	fsys, err := t.storage()
+	if err != nil {
+		return nil, err
+	}
+	names, err := listPages(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+	cmdIndex := buildIndex(names)
+	if len(cmdIndex.Commands) == 0 {
+		return nil, errors.New("no pages to index")
+	}
+
+	if err := writeJSON(filepath.Join(t.dbPath(), indexFileName), cmdIndex); err != nil {
+		return nil, fmt.Errorf("failed to save a index file: %w", err)
+	}
+	return cmdIndex, nil
+}
+
+// indexOutdated returns true if pages are added or deleted by hand after the index file is written.
+// It compares modification times of the index file and page dirs of an extracted database
+func (t *Tldr) indexOutdated() bool {
+	if t.customStorage != nil || pathExists(filepath.Join(t.dbPath(), archiveFileName)) {
+		return false
+	}
+
+	fi, err := os.Stat(t.indexFilePath())
+	if err != nil {
+		return false
+	}
+	indexTime := fi.ModTime()
+
+	entries, err := os.ReadDir(t.dbPath())
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if _, ok := langFromDir(e.Name()); !ok || !e.IsDir() {
+			continue
+		}
+		langDir := filepath.Join(t.dbPath(), e.Name())
+		dirs := []string{langDir}
+		pts, err := os.ReadDir(langDir)
+		if err != nil {
+			return false
+		}
+		for _, pt := range pts {
+			if pt.IsDir() {
+				dirs = append(dirs, filepath.Join(langDir, pt.Name()))
+			}
+		}
+		for _, dir := range dirs {
+			if fi, err := os.Stat(dir); err == nil && fi.ModTime().After(indexTime) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listPages returns paths of files in the page dirs `pages[.lang]` of `fsys`.
+// Other dirs such as .git of a local repository are not walked
+func listPages(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, ok := langFromDir(e.Name()); !ok {
+			continue
+		}
+		files, err := listFiles(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, files...)
+	}
+	return names, nil
+}
+
+// buildIndex builds a command index of pages in `names` as index.json of tldr-pages does
+func buildIndex(names []string) *CmdsIndex {
+	byName := make(map[string]*CmdInfo)
+	for _, name := range names {
+		lang, pt, cmd, ok := parsePagePath(name)
+		if !ok {
+			continue
+		}
+		info, ok := byName[cmd]
+		if !ok {
+			info = &CmdInfo{Name: cmd}
+			byName[cmd] = info
+		}
+		info.Platforms = appendPlatform(info.Platforms, pt)
+		info.Languages = appendString(info.Languages, lang)
+		info.Targets = appendTarget(info.Targets, Target{OS: pt, Language: lang})
+	}
+
+	cmds := make(Cmds, 0, len(byName))
+	for _, info := range byName {
+		sort.Slice(info.Platforms, func(i, j int) bool { return info.Platforms[i] < info.Platforms[j] })
+		sort.Strings(info.Languages)
+		sort.Slice(info.Targets, func(i, j int) bool {
+			if info.Targets[i].OS != info.Targets[j].OS {
+				return info.Targets[i].OS < info.Targets[j].OS
+			}
+			return info.Targets[i].Language < info.Targets[j].Language
+		})
+		cmds = append(cmds, info)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return &CmdsIndex{Commands: cmds}
+}
+
+// mergeIndex adds commands of `src` to `dst`. Platforms, languages and targets of the same command are merged
+func mergeIndex(dst, src *CmdsIndex) {
+	byName := make(map[string]*CmdInfo, len(dst.Commands))
+	for _, info := range dst.Commands {
+		byName[info.Name] = info
+	}
+	for _, info := range src.Commands {
+		cur, ok := byName[info.Name]
+		if !ok {
+			dst.Commands = append(dst.Commands, info)
+			byName[info.Name] = info
+			continue
+		}
+		for _, pt := range info.Platforms {
+			cur.Platforms = appendPlatform(cur.Platforms, pt)
+		}
+		for _, lang := range info.Languages {
+			cur.Languages = appendString(cur.Languages, lang)
+		}
+		for _, tg := range info.Targets {
+			cur.Targets = appendTarget(cur.Targets, tg)
+		}
+		cur.Custom = cur.Custom || info.Custom
+	}
+}
+
+func appendPlatform(pts []Platform, pt Platform) []Platform {
+	for _, v := range pts {
+		if v == pt {
+			return pts
+		}
+	}
+	return append(pts, pt)
+}
+
+func appendTarget(tgs []Target, tg Target) []Target {
+	for _, v := range tgs {
+		if v == tg {
+			return tgs
+		}
+	}
+	return append(tgs, tg)
+}
+
+func appendString(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
@@ -0,0 +1,250 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildIndex(t *testing.T) {
+	names := []string{
+		"index.json",
+		"pages/common/tar.md",
+		"pages/linux/tar.md",
+		"pages.ja/common/tar.md",
+		"pages/osx/archey.md",
+		"pages/common/README.md.txt",
+		"pages/common/nested/dir.md",
+		"other/common/ignored.md",
+	}
+	want := &CmdsIndex{
+		Commands: Cmds{
+			{
+				Name: "archey", Platforms: []Platform{PlatformOSX}, Languages: []string{"en"},
+				Targets: []Target{{OS: PlatformOSX, Language: "en"}},
+			},
+			{
+				Name: "tar", Platforms: []Platform{PlatformCommon, PlatformLinux}, Languages: []string{"en", "ja"},
+				Targets: []Target{
+					{OS: PlatformCommon, Language: "en"},
+					{OS: PlatformCommon, Language: "ja"},
+					{OS: PlatformLinux, Language: "en"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, buildIndex(names)); diff != "" {
+		t.Errorf("-want +got\n%s", diff)
+	}
+}
+
+func TestMergeIndex(t *testing.T) {
+	dst := &CmdsIndex{
+		Commands: Cmds{
+			{
+				Name: "tar", Platforms: []Platform{PlatformCommon}, Languages: []string{"en"},
+				Targets: []Target{{OS: PlatformCommon, Language: "en"}},
+			},
+		},
+	}
+	src := &CmdsIndex{
+		Commands: Cmds{
+			{
+				Name: "tar", Platforms: []Platform{PlatformLinux}, Languages: []string{"en", "ja"}, Custom: true,
+				Targets: []Target{{OS: PlatformLinux, Language: "en"}, {OS: PlatformLinux, Language: "ja"}},
+			},
+			{Name: "mytool", Platforms: []Platform{PlatformCommon}, Languages: []string{"en"}, Custom: true},
+		},
+	}
+	want := &CmdsIndex{
+		Commands: Cmds{
+			{
+				Name: "tar", Platforms: []Platform{PlatformCommon, PlatformLinux}, Languages: []string{"en", "ja"}, Custom: true,
+				Targets: []Target{
+					{OS: PlatformCommon, Language: "en"},
+					{OS: PlatformLinux, Language: "en"},
+					{OS: PlatformLinux, Language: "ja"},
+				},
+			},
+			{Name: "mytool", Platforms: []Platform{PlatformCommon}, Languages: []string{"en"}, Custom: true},
+		},
+	}
+	mergeIndex(dst, src)
+	if diff := cmp.Diff(want, dst); diff != "" {
+		t.Errorf("-want +got\n%s", diff)
+	}
+}
+
+func TestOverlayDirs(t *testing.T) {
+	overlay := t.TempDir()
+	writePage := func(name, body string) {
+		t.Helper()
+		p := filepath.Join(overlay, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePage("pages/common/mytool.md", "# mytool\n\n> An internal tool.\n")
+	writePage("pages/common/lsof.md", "# custom lsof\n\n> Overridden.\n")
+
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	missing := filepath.Join(t.TempDir(), "missing")
+	tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"), WithOverlayDirs(missing, overlay))
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		cmds        []string
+		want        string
+	}{
+		{
+			description: "custom only page",
+			cmds:        []string{"mytool"},
+			want:        "mytool",
+		},
+		{
+			description: "custom page overrides official one",
+			cmds:        []string{"lsof"},
+			want:        "custom lsof",
+		},
+		{
+			description: "official page",
+			cmds:        []string{"git", "checkout"},
+			want:        "git checkout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			page, err := tldr.FindPage(tt.cmds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.CmdName != tt.want {
+				t.Errorf("want: %s, got: %s", tt.want, page.CmdName)
+			}
+		})
+	}
+
+	index, err := tldr.LoadIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := map[string]bool{}
+	for _, cmd := range index.Commands {
+		custom[cmd.Name] = cmd.Custom
+	}
+	for name, want := range map[string]bool{"mytool": true, "lsof": true, "git-checkout": false} {
+		got, ok := custom[name]
+		if !ok {
+			t.Errorf("%s is not in the index", name)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: want custom %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestReindex(t *testing.T) {
+	t.Run("repair a missing index file", func(t *testing.T) {
+		tldrPath := filepath.Join(t.TempDir(), ".tldr")
+		tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(tldr.indexFilePath()); err != nil {
+			t.Fatal(err)
+		}
+
+		tldr = New(tldrPath, WithTestZipURL(), WithLanguage("en"))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if !pathExists(tldr.indexFilePath()) {
+			t.Errorf("index file is not repaired")
+		}
+	})
+
+	t.Run("pages added and deleted by hand", func(t *testing.T) {
+		tldrPath := filepath.Join(t.TempDir(), ".tldr")
+		tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		index, err := tldr.LoadIndexFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tldr.indexOutdated() {
+			t.Errorf("index file is outdated just after update")
+		}
+		before := len(index.Commands)
+
+		// Note make sure mtime of dirs is newer than the index file
+		past := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(tldr.indexFilePath(), past, past); err != nil {
+			t.Fatal(err)
+		}
+		pagesDir := filepath.Join(tldr.dbPath(), "pages", "common")
+		if err := os.WriteFile(filepath.Join(pagesDir, "handmade.md"), []byte("# handmade\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(filepath.Join(pagesDir, "lsof.md")); err != nil {
+			t.Fatal(err)
+		}
+
+		index, err = tldr.LoadIndexFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := map[string]bool{}
+		for _, cmd := range index.Commands {
+			names[cmd.Name] = true
+		}
+		if !names["handmade"] || names["lsof"] {
+			t.Errorf("index file is not rebuilt: %v", names)
+		}
+		if len(index.Commands) != before {
+			t.Errorf("want: %d commands, got: %d", before, len(index.Commands))
+		}
+		if tldr.indexOutdated() {
+			t.Errorf("index file is outdated after reindex")
+		}
+	})
+
+	t.Run("zip storage", func(t *testing.T) {
+		tldrPath := filepath.Join(t.TempDir(), ".tldr")
+		tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"), WithZipStorage())
+		t.Cleanup(func() { tldr.Close() })
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tldr.Reindex(); err != nil {
+			t.Fatal(err)
+		}
+		if !pathExists(filepath.Join(tldr.dbPath(), indexFileName)) {
+			t.Errorf("index file is not placed next to the zip")
+		}
+		if _, err := tldr.LoadIndexFile(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("read-only storage", func(t *testing.T) {
+		tldr := New(t.TempDir(), WithStorage(fstest.MapFS{}))
+		if _, err := tldr.Reindex(); !errors.Is(err, ErrReadOnlyStorage) {
+			t.Errorf("want: %v, got: %v", ErrReadOnlyStorage, err)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LanguageArchiveURL is the default url template of per-language archives
+const LanguageArchiveURL = "https://github.com/tldr-pages/tldr/releases/latest/download/tldr-pages.{lang}.zip"
+
+// languagePlaceholder is replaced with a language code in the url template
+const languagePlaceholder = "{lang}"
+
+// WithLanguageArchives downloads only the archives of the configured languages and English
+// instead of the whole database. `urlTemplate` must contain `{lang}` and an empty one means LanguageArchiveURL.
+// Each archive is verified with its own sidecars, so use WithChecksumVerification instead of WithChecksum.
+// The archives are always extracted even if WithZipStorage is specified
+func WithLanguageArchives(urlTemplate string) Option {
+	return func(t *Tldr) {
+		if urlTemplate == "" {
+			urlTemplate = LanguageArchiveURL
+		}
+		t.langArchiveURL = urlTemplate
+	}
+}
+
+// archiveLanguages returns languages to download in priority order.
+// They are the languages from the environment and English.
+// Note a language selected by WithLanguage is not included not to download archives for a one-off query
+func (t *Tldr) archiveLanguages() []string {
+	return getLanguages("")
+}
+
+// languagesMissing returns true if the database is not installed for all of the configured languages
+func (t *Tldr) languagesMissing() bool {
+	if t.langArchiveURL == "" || t.customStorage != nil {
+		return false
+	}
+	m, err := t.Manifest()
+	// Note a whole database installed by Install or before enabling archives has all languages
+	if err != nil || len(m.Languages) == 0 {
+		return false
+	}
+	for _, lang := range t.archiveLanguages() {
+		if !contains(lang, m.Languages) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateLanguages downloads the per-language archives and installs them as a database
+func (t *Tldr) updateLanguages(ctx context.Context) (*UpdateResult, error) {
+	urlTemplate := t.languageArchiveURL()
+	if !strings.Contains(urlTemplate, languagePlaceholder) {
+		return nil, fmt.Errorf("no %s in the url of language archives: %s", languagePlaceholder, urlTemplate)
+	}
+
+	unlock, err := t.prepareUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	langs := t.archiveLanguages()
+	current, err := t.Manifest()
+	conditional := err == nil && t.hasIndexFile() &&
+		len(current.Languages) == len(langs) && !t.languagesMissing() &&
+		(t.publicKey == nil || t.publicKey.KeyID() == current.SignatureKeyID)
+
+	archives := make(map[string]*archive, len(langs))
+	notModified := make([]string, 0, len(langs))
+	modified := !conditional
+	for _, lang := range langs {
+		var cond *validators
+		if conditional {
+			cond = current.archiveValidators(lang)
+		}
+		a, err := t.fetchLanguage(ctx, lang, cond)
+		if errors.Is(err, errNotModified) {
+			notModified = append(notModified, lang)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Note an archive withdrawn upstream also changes the database
+		if a != nil || (conditional && current.archiveValidators(lang) != nil) {
+			modified = true
+		}
+		archives[lang] = a
+	}
+
+	if !modified {
+		return t.refresh(current)
+	}
+
+	// Note pages of not modified archives are reused from the current database
+	diff, err := t.installLanguages(ctx, langs, archives, current, notModified)
+	if err != nil {
+		return nil, err
+	}
+	installed := make([]*archive, 0, len(archives))
+	for _, a := range archives {
+		installed = append(installed, a)
+	}
+	return t.installed(diff, urlTemplate, installed...), nil
+}
+
+// fetchLanguage downloads and verifies the archive of `lang`.
+// It returns nil if no archive is published for the language except English
+func (t *Tldr) fetchLanguage(ctx context.Context, lang string, cond *validators) (*archive, error) {
+	u := strings.ReplaceAll(t.languageArchiveURL(), languagePlaceholder, lang)
+	a, err := t.downloader.downloadWithRetry(ctx, u, t.path, "tldr-pages."+lang+".zip", cond)
+	if errors.Is(err, errNotModified) {
+		return nil, err
+	}
+	if err != nil {
+		if lang != languageCodeEN && isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to download a tldr repository: %w", err)
+	}
+
+	if err := t.verify(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// installLanguages extracts `archives` into language dirs of a staging directory,
+// builds the index file and swaps it in.
+// The language dirs of `reused` are taken from the current database described by `current`
+func (t *Tldr) installLanguages(ctx context.Context, langs []string, archives map[string]*archive,
+	current *Manifest, reused []string) (*pageDiff, error) {
+	return t.stage(func(stagingDir string) (fs.FS, *Manifest, error) {
+		m := t.newManifest(t.languageArchiveURL())
+		m.Languages = langs
+		for _, lang := range langs {
+			if contains(lang, reused) {
+				langDir := getLangDir(lang)
+				if err := linkTree(filepath.Join(t.dbPath(), langDir), filepath.Join(stagingDir, langDir)); err != nil {
+					return nil, nil, fmt.Errorf("failed to reuse pages of %s: %w", lang, err)
+				}
+				if info := current.archiveInfo(lang); info != nil {
+					m.ArchiveSize += info.Size
+					m.Archives = append(m.Archives, *info)
+				}
+				continue
+			}
+			a := archives[lang]
+			if a == nil {
+				continue
+			}
+			if err := extractLanguage(ctx, a.path, stagingDir, lang); err != nil {
+				return nil, nil, fmt.Errorf("failed to unzip a tldr repository of %s: %w", lang, err)
+			}
+			m.ArchiveSize += a.size
+			m.Archives = append(m.Archives, ArchiveInfo{
+				Language:     lang,
+				URL:          a.URL,
+				Size:         a.size,
+				SHA256:       a.sha256,
+				ETag:         a.ETag,
+				LastModified: a.LastModified,
+			})
+		}
+
+		fsys := os.DirFS(stagingDir)
+		names, err := listPages(fsys)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list pages: %w", err)
+		}
+		// Note the archives do not have the index file
+		if err := writeJSON(filepath.Join(stagingDir, indexFileName), buildIndex(names)); err != nil {
+			return nil, nil, fmt.Errorf("failed to save a index file: %w", err)
+		}
+		return fsys, m, nil
+	})
+}
+
+// extractLanguage extracts the archive of `lang` into the language dir of `dstDir`.
+// The archive has platform dirs at the root or the language dir
+func extractLanguage(ctx context.Context, zipPath, dstDir, lang string) error {
+	tmpDir, err := os.MkdirTemp(dstDir, ".lang-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := unzip(ctx, zipPath, tmpDir); err != nil {
+		return err
+	}
+	langDir := getLangDir(lang)
+	src := tmpDir
+	if fi, err := os.Stat(filepath.Join(tmpDir, langDir)); err == nil && fi.IsDir() {
+		src = filepath.Join(tmpDir, langDir)
+	}
+	return os.Rename(src, filepath.Join(dstDir, langDir))
+}
+
+// linkTree creates `dstDir` with hard links or copies of the files in `srcDir`
+func linkTree(srcDir, dstDir string) error {
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return linkOrCopy(path, dst)
+	})
+}
@@ -0,0 +1,201 @@
+package tldr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLanguageArchives(t *testing.T) {
+	t.Setenv("LANG", "")
+	t.Setenv("LANGUAGE", "")
+	archiveDir := t.TempDir()
+	writeArchive := func(t *testing.T, lang string, entries []zipEntry) string {
+		t.Helper()
+		path := filepath.Join(archiveDir, "tldr-pages."+lang+".zip")
+		if err := os.Rename(writeTestZip(t, entries), path); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeArchive(t, "en", []zipEntry{
+		{name: "common/tar.md", body: "# tar\n"},
+		{name: "linux/ls.md", body: "# ls\n"},
+		{name: "LICENSE.md", body: "license"},
+	})
+	// Note the layout with the language dir is also accepted
+	writeArchive(t, "ja", []zipEntry{
+		{name: "pages.ja/common/tar.md", body: "# tar ja\n"},
+	})
+	writeArchive(t, "fr", []zipEntry{
+		{name: "common/tar.md", body: "# tar fr\n"},
+	})
+	urlTemplate := filepath.Join(archiveDir, "tldr-pages.{lang}.zip")
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+
+	installedLanguages := func(t *testing.T) []string {
+		t.Helper()
+		m, err := New(tldrPath).Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		langs := []string{}
+		for lang := range m.Pages {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		return langs
+	}
+
+	t.Run("install only configured languages", func(t *testing.T) {
+		t.Setenv("LANG", "ja_JP.UTF-8")
+		tldr := New(tldrPath, WithLanguageArchives(urlTemplate))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"en", "ja"}, installedLanguages(t)); diff != "" {
+			t.Errorf("-want +got\n%s", diff)
+		}
+		page, err := tldr.FindPage([]string{"tar"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.CmdName != "tar ja" {
+			t.Errorf("want: tar ja, got: %s", page.CmdName)
+		}
+		index, err := tldr.LoadIndexFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(index.Commands) != 2 {
+			t.Errorf("want: tar and ls, got: %+v", index.Commands)
+		}
+
+		got, err := tldr.Update(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.NotModified {
+			t.Errorf("unchanged archives should not be installed again")
+		}
+		if New(tldrPath, WithLanguage("fr"), WithLanguageArchives(urlTemplate)).ConfigChanged() {
+			t.Errorf("a language selected for a query should not be fetched")
+		}
+	})
+
+	t.Run("update if one of the archives is modified", func(t *testing.T) {
+		t.Setenv("LANG", "ja_JP.UTF-8")
+		jaZip := writeArchive(t, "ja", []zipEntry{
+			{name: "pages.ja/common/tar.md", body: "# tar ja new\n"},
+		})
+		future := time.Now().Add(time.Hour)
+		if err := os.Chtimes(jaZip, future, future); err != nil {
+			t.Fatal(err)
+		}
+		enPage := filepath.Join(tldrPath, currentDBName, "pages", "common", "tar.md")
+		before, err := os.Stat(enPage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tldr := New(tldrPath, WithLanguageArchives(urlTemplate))
+		got, err := tldr.Update(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.NotModified {
+			t.Fatalf("modified archive should be installed")
+		}
+		after, err := os.Stat(enPage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !os.SameFile(before, after) {
+			t.Errorf("pages of not modified archive should be reused from the current database")
+		}
+		m, err := tldr.Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.archiveValidators(languageCodeEN) == nil {
+			t.Errorf("validators of not modified archive should be kept")
+		}
+		page, err := tldr.FindPage([]string{"tar"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.CmdName != "tar ja new" {
+			t.Errorf("want: tar ja new, got: %s", page.CmdName)
+		}
+		if _, err := New(tldrPath, WithPlatform(PlatformLinux)).FindPage([]string{"ls"}); err != nil {
+			t.Errorf("not modified archive should be kept: %v", err)
+		}
+	})
+
+	t.Run("re-fetch by an update if the language setting changes", func(t *testing.T) {
+		t.Setenv("LANG", "fr_FR.UTF-8")
+		tldr := New(tldrPath, WithLanguageArchives(urlTemplate))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"en", "ja"}, installedLanguages(t)); diff != "" {
+			t.Errorf("archives should not be fetched on initialize: -want +got\n%s", diff)
+		}
+		if !tldr.ConfigChanged() {
+			t.Errorf("want: config changed, got: not changed")
+		}
+		if _, err := tldr.Update(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"en", "fr"}, installedLanguages(t)); diff != "" {
+			t.Errorf("-want +got\n%s", diff)
+		}
+		if tldr.ConfigChanged() {
+			t.Errorf("want: config not changed, got: changed")
+		}
+	})
+
+	t.Run("skip a language without an archive", func(t *testing.T) {
+		t.Setenv("LANG", "xx")
+		tldr := New(tldrPath, WithLanguageArchives(urlTemplate))
+		if _, err := tldr.Update(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"en"}, installedLanguages(t)); diff != "" {
+			t.Errorf("-want +got\n%s", diff)
+		}
+		if tldr.languagesMissing() {
+			t.Errorf("a language without an archive should not be fetched again")
+		}
+	})
+
+	t.Run("a whole database has all languages", func(t *testing.T) {
+		t.Setenv("LANG", "ja_JP.UTF-8")
+		tldr := New(filepath.Join(t.TempDir(), ".tldr"), WithLanguageArchives(urlTemplate))
+		if _, err := tldr.Install(context.TODO(), testServer.TldrZipPath()); err != nil {
+			t.Fatal(err)
+		}
+		if tldr.ConfigChanged() {
+			t.Errorf("an installed database should not be fetched again")
+		}
+	})
+
+	t.Run("fail without the English archive", func(t *testing.T) {
+		tldr := New(filepath.Join(t.TempDir(), ".tldr"),
+			WithLanguageArchives(filepath.Join(t.TempDir(), "tldr-pages.{lang}.zip")))
+		if _, err := tldr.Update(context.TODO()); err == nil {
+			t.Errorf("expect error happens")
+		}
+	})
+
+	t.Run("fail without the placeholder", func(t *testing.T) {
+		tldr := New(filepath.Join(t.TempDir(), ".tldr"), WithLanguageArchives(testServer.TldrZipURL()))
+		if _, err := tldr.Update(context.TODO()); err == nil {
+			t.Errorf("expect error happens")
+		}
+	})
+}
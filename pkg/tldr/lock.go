@@ -0,0 +1,91 @@
+package tldr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const lockFileName = ".lock"
+
+var ErrLocked = errors.New("tldr database is locked by another process")
+
+var (
+	// lockRetryInterval is the interval to check the lock released
+	lockRetryInterval = 100 * time.Millisecond
+	// lockWaitTimeout is the maximum time to wait for the lock in addition to the context
+	lockWaitTimeout = 30 * time.Second
+)
+
+// lockInfo is the content of the lock file for troubleshooting
+type lockInfo struct {
+	PID       int       `json:"pid"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// lock acquires the lock of the data dir to serialize updates across processes.
+// It waits for the lock released until the context is done
+func (t *Tldr) lock(ctx context.Context) (unlock func(), err error) {
+	ctx, cancel := context.WithTimeout(ctx, lockWaitTimeout)
+	defer cancel()
+
+	for {
+		unlock, err := t.tryLock()
+		if !errors.Is(err, ErrLocked) {
+			return unlock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %s", ErrLocked, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// tryLock acquires the lock without waiting. ErrLocked is returned if another process holds it.
+// The lock is flock(2) on the lock file, so it is released by the kernel even if the holder crashes.
+// Note the lock file is never removed since another process may be waiting for the lock of the same file
+func (t *Tldr) tryLock() (unlock func(), err error) {
+	path := filepath.Join(t.path, lockFileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("failed to lock: %w", err)
+	}
+
+	if err := f.Truncate(0); err == nil {
+		_ = json.NewEncoder(f).Encode(&lockInfo{PID: os.Getpid(), CreatedAt: time.Now()})
+	}
+
+	t.cleanStaging()
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// cleanStaging removes staging dirs and links left by crashed processes. The lock must be held
+func (t *Tldr) cleanStaging() {
+	entries, err := os.ReadDir(t.path)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), stagingPrefix) {
+			_ = os.RemoveAll(filepath.Join(t.path, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTryLock(t *testing.T) {
+	tests := []struct {
+		name      string
+		crashed   bool
+		expectErr bool
+	}{
+		{
+			name:      "held by a live process",
+			expectErr: true,
+		},
+		{
+			name:    "released by a crashed process",
+			crashed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+				t.Fatal(err)
+			}
+			if tt.crashed {
+				// Note the kernel releases the lock when the holder exits without unlock
+				f.Close()
+			}
+
+			unlock, err := New(dir).tryLock()
+			if tt.expectErr {
+				if !errors.Is(err, ErrLocked) {
+					t.Errorf("want: %v, got: %v", ErrLocked, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error got: %+v", err)
+			}
+			unlock()
+			unlock, err = New(dir).tryLock()
+			if err != nil {
+				t.Fatalf("lock is not released after unlock: %+v", err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestLockExclusive(t *testing.T) {
+	dir := t.TempDir()
+
+	var (
+		wg      sync.WaitGroup
+		active  int32
+		overlap int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := New(dir).lock(context.TODO())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if atomic.AddInt32(&active, 1) > 1 {
+				atomic.StoreInt32(&overlap, 1)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if overlap != 0 {
+		t.Errorf("the lock is held by multiple holders at the same time")
+	}
+}
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	staging := filepath.Join(dir, stagingPrefix+"crashed")
+	if err := os.Mkdir(staging, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tldr := New(dir)
+	unlock, err := tldr.lock(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pathExists(staging) {
+		t.Errorf("staging dir left by a crashed process remains")
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 3*lockRetryInterval)
+	defer cancel()
+	if _, err := New(dir).lock(ctx); !errors.Is(err, ErrLocked) {
+		t.Errorf("want: %v, got: %v", ErrLocked, err)
+	}
+
+	// Note the lock is acquired after released by another holder
+	time.AfterFunc(2*lockRetryInterval, unlock)
+	unlock2, err := New(dir).lock(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock2()
+}
+
+func TestConcurrentInitialize(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"))
+			if err := tldr.OnInitialize(context.TODO()); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := tldr.FindPage([]string{"lsof"}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	snapshots, err := New(tldrPath).Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Errorf("want: 1 snapshot, got: %d", len(snapshots))
+	}
+	unlock, err := New(tldrPath).tryLock()
+	if err != nil {
+		t.Errorf("lock is not released: %v", err)
+		return
+	}
+	unlock()
+}
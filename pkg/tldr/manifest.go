@@ -0,0 +1,174 @@
+package tldr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const manifestFileName = "manifest.json"
+
+// Manifest metadata of an installed tldr database
+type Manifest struct {
+	SourceURL    string    `json:"source_url"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	// CheckedAt is the last time when the database was confirmed as the latest
+	CheckedAt     time.Time `json:"checked_at"`
+	ArchiveSize   int64     `json:"archive_size"`
+	ArchiveSHA256 string    `json:"archive_sha256"`
+	ETag          string    `json:"etag,omitempty"`
+	LastModified  string    `json:"last_modified,omitempty"`
+	// Pages is the number of pages per language and platform
+	Pages         map[string]map[Platform]int `json:"pages"`
+	ClientVersion string                      `json:"client_version,omitempty"`
+	// SignatureKeyID is the id of the public key which verified the archive
+	SignatureKeyID string `json:"signature_key_id,omitempty"`
+	// ReleaseTag is the tldr-pages release which the database is pinned to
+	ReleaseTag string `json:"release_tag,omitempty"`
+	// PinnedTag is the release tag configured when the database was installed.
+	// It is recorded even if the database was installed from another source, e.g.) by Install
+	PinnedTag string `json:"pinned_tag,omitempty"`
+	// Languages are the requested languages if the database is installed from per-language archives
+	Languages []string `json:"languages,omitempty"`
+	// Archives are the installed per-language archives
+	Archives []ArchiveInfo `json:"archives,omitempty"`
+	// RolledBack is true if the database was made current by Rollback until the next update
+	RolledBack bool `json:"rolled_back,omitempty"`
+}
+
+// ArchiveInfo metadata of a per-language archive
+type ArchiveInfo struct {
+	Language     string `json:"language"`
+	URL          string `json:"url"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Age return the time since the database was confirmed as the latest
+func (m *Manifest) Age() time.Duration {
+	return time.Since(m.CheckedAt)
+}
+
+func (m *Manifest) validators() *validators {
+	return &validators{
+		URL:          m.SourceURL,
+		ETag:         m.ETag,
+		LastModified: m.LastModified,
+	}
+}
+
+// archiveInfo returns the installed archive of `lang` or nil
+func (m *Manifest) archiveInfo(lang string) *ArchiveInfo {
+	for i := range m.Archives {
+		if m.Archives[i].Language == lang {
+			return &m.Archives[i]
+		}
+	}
+	return nil
+}
+
+// archiveValidators returns validators of the installed archive of `lang` or nil
+func (m *Manifest) archiveValidators(lang string) *validators {
+	a := m.archiveInfo(lang)
+	if a == nil {
+		return nil
+	}
+	return &validators{
+		URL:          a.URL,
+		ETag:         a.ETag,
+		LastModified: a.LastModified,
+	}
+}
+
+// removeLanguage removes the metadata of `lang`
+func (m *Manifest) removeLanguage(lang string) {
+	delete(m.Pages, lang)
+	langs := m.Languages[:0]
+	for _, l := range m.Languages {
+		if l != lang {
+			langs = append(langs, l)
+		}
+	}
+	m.Languages = langs
+	archives := m.Archives[:0]
+	for _, a := range m.Archives {
+		if a.Language != lang {
+			archives = append(archives, a)
+		}
+	}
+	m.Archives = archives
+}
+
+// Manifest return the metadata of the current database
+func (t *Tldr) Manifest() (*Manifest, error) {
+	if t.customStorage != nil {
+		return readManifest(t.customStorage)
+	}
+	return readManifest(os.DirFS(t.dbPath()))
+}
+
+func readManifest(fsys fs.FS) (*Manifest, error) {
+	f, err := fsys.Open(manifestFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a manifest: %w", err)
+	}
+	defer f.Close()
+
+	m := &Manifest{}
+	if err := json.NewDecoder(f).Decode(m); err != nil {
+		return nil, fmt.Errorf("failed to parse a manifest: %w", err)
+	}
+	return m, nil
+}
+
+func writeManifest(dir string, m *Manifest) error {
+	return writeJSON(filepath.Join(dir, manifestFileName), m)
+}
+
+// countPages return the number of pages per language and platform in `names`
+func countPages(names []string) map[string]map[Platform]int {
+	counts := make(map[string]map[Platform]int)
+	for _, name := range names {
+		lang, pt, _, ok := parsePagePath(name)
+		if !ok {
+			continue
+		}
+		if counts[lang] == nil {
+			counts[lang] = make(map[Platform]int)
+		}
+		counts[lang][pt]++
+	}
+	return counts
+}
+
+// parsePagePath splits `pages[.lang]/<platform>/<cmd>.md`
+func parsePagePath(name string) (lang string, pt Platform, cmd string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || path.Ext(parts[2]) != ".md" {
+		return "", "", "", false
+	}
+	lang, ok = langFromDir(parts[0])
+	if !ok {
+		return "", "", "", false
+	}
+	return lang, Platform(parts[1]), strings.TrimSuffix(parts[2], ".md"), true
+}
+
+// langFromDir is the reverse of getLangDir
+func langFromDir(name string) (string, bool) {
+	pagesDir := getLangDir(languageCodeEN)
+	if name == pagesDir {
+		return languageCodeEN, true
+	}
+	if lang := strings.TrimPrefix(name, pagesDir+"."); lang != name && lang != "" {
+		return lang, true
+	}
+	return "", false
+}
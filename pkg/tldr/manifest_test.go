@@ -0,0 +1,92 @@
+package tldr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifest(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithTestZipURL(), WithClientVersion("v1.0.0"))
+	if _, err := tldr.Manifest(); err == nil {
+		t.Fatal("expect error happens before update, but got response")
+	}
+
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := tldr.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.SourceURL != testServer.TldrZipURL() {
+		t.Errorf("want: %s, got: %s", testServer.TldrZipURL(), m.SourceURL)
+	}
+	if m.ClientVersion != "v1.0.0" {
+		t.Errorf("want: v1.0.0, got: %s", m.ClientVersion)
+	}
+	if m.ArchiveSize == 0 || len(m.ArchiveSHA256) != 64 || m.ETag == "" {
+		t.Errorf("archive information is missing: %+v", m)
+	}
+	if time.Since(m.DownloadedAt) > time.Minute || m.Age() > time.Minute {
+		t.Errorf("unexpected download time: %+v", m)
+	}
+	if m.Pages[languageCodeEN][PlatformCommon] == 0 {
+		t.Errorf("no common pages in en: %+v", m.Pages)
+	}
+}
+
+func TestExpiredWithManifest(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithTestZipURL())
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mtime of index file should not affect the age
+	past := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(tldr.indexFilePath(), past, past); err != nil {
+		t.Fatal(err)
+	}
+	if tldr.Expired(time.Hour) {
+		t.Errorf("want: not expired, got: expired")
+	}
+
+	m, err := tldr.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.CheckedAt = past
+	if err := writeManifest(tldr.dbPath(), m); err != nil {
+		t.Fatal(err)
+	}
+	if !tldr.Expired(time.Hour) {
+		t.Errorf("want: expired, got: not expired")
+	}
+}
+
+func TestLangFromDir(t *testing.T) {
+	tests := []struct {
+		dir    string
+		want   string
+		wantOK bool
+	}{
+		{dir: "pages", want: "en", wantOK: true},
+		{dir: "pages.ja", want: "ja", wantOK: true},
+		{dir: "pages.pt_BR", want: "pt_BR", wantOK: true},
+		{dir: "pages.", want: "", wantOK: false},
+		{dir: "index.json", want: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, ok := langFromDir(tt.dir)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("want: %s %v, got: %s %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
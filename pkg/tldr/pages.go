@@ -2,10 +2,16 @@ package tldr
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,10 +29,17 @@ const (
 const (
 	PageSourceURL  = "https://tldr.sh/assets/tldr.zip"
 	languageCodeEN = "en"
+	indexFileName  = "index.json"
+	// currentDBName is a symlink pointing to the database in use
+	currentDBName = "current"
+	dbDirPrefix   = "db-"
+	stagingPrefix = ".staging-"
 )
 
 var (
-	ErrNotFoundPage = errors.New("no page found")
+	ErrNotFoundPage    = errors.New("no page found")
+	ErrReadOnlyStorage = errors.New("read-only storage cannot be updated")
+	ErrNotLocalFile    = errors.New("not a local file")
 )
 
 func (pt Platform) String() string {
@@ -54,31 +67,142 @@ func WithForceUpdate() Option {
 	}
 }
 
+// WithoutInitialUpdate does not download the first database in OnInitialize.
+// This is useful to install the first database by Install without network access
+func WithoutInitialUpdate() Option {
+	return func(t *Tldr) {
+		t.skipInitUpdate = true
+	}
+}
+
 // WithRepositoryURL replaces default tldr remote url
 // This is useful for local test
 func WithRepositoryURL(u string) Option {
+	return WithRepositoryURLs(u)
+}
+
+// WithRepositoryURLs replaces default tldr remote url with mirrors.
+// Update tries the urls in order until one of them succeeds
+func WithRepositoryURLs(urls ...string) Option {
+	return func(t *Tldr) {
+		if len(urls) > 0 {
+			t.pageSourceURLs = urls
+		}
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(t *Tldr) {
+		t.downloader.policy = p
+	}
+}
+
+// WithHTTPClient replaces the http client for downloading
+// e.g.) to use a proxy or a custom CA
+func WithHTTPClient(c *http.Client) Option {
+	return func(t *Tldr) {
+		if c != nil {
+			t.downloader.client = c
+		}
+	}
+}
+
+// WithHTTPHeader adds a header to requests to `host` for downloading
+// e.g.) Authorization header for a private mirror.
+// `host` is a host name or a host with a port of the url. The header is not sent to other hosts even on redirects
+func WithHTTPHeader(host, key, value string) Option {
+	return func(t *Tldr) {
+		host = strings.ToLower(host)
+		if t.downloader.headers[host] == nil {
+			t.downloader.headers[host] = make(http.Header)
+		}
+		t.downloader.headers[host].Add(key, value)
+	}
+}
+
+// WithStorage reads pages from `fsys` instead of the database in the data dir.
+// `fsys` must have the layout of tldr.zip, e.g.) os.DirFS, *zip.Reader, embed.FS or fstest.MapFS.
+// The storage is read-only and Update returns ErrReadOnlyStorage
+func WithStorage(fsys fs.FS) Option {
 	return func(t *Tldr) {
-		t.pageSourceURL = u
+		t.customStorage = fsys
+	}
+}
+
+// WithLocalRepository reads pages from a local clone of tldr-pages at `dir`.
+// The index is built by walking the pages as the clone does not have index.json
+func WithLocalRepository(dir string) Option {
+	return WithStorage(os.DirFS(dir))
+}
+
+// WithOverlayDirs adds directories of custom pages which have the same layout as the database.
+// The pages are preferred to the official ones and are merged into the index
+func WithOverlayDirs(dirs ...string) Option {
+	return func(t *Tldr) {
+		for _, dir := range dirs {
+			t.overlays = append(t.overlays, os.DirFS(dir))
+		}
+	}
+}
+
+// WithClientVersion records the client version in the manifest of the database
+func WithClientVersion(v string) Option {
+	return func(t *Tldr) {
+		t.clientVersion = v
 	}
 }
 
 // Tldr Repository of tldir pages
 type Tldr struct {
-	path          string
-	pageSourceURL string
-	platforms     []Platform
-	languages     []string
-	update        bool
+	path            string
+	pageSourceURLs  []string
+	downloader      *downloader
+	platforms       []Platform
+	languages       []string
+	update          bool
+	skipInitUpdate  bool
+	zipStorage      bool
+	store           *zipStore
+	customStorage   fs.FS
+	snapshots       int
+	overlays        []fs.FS
+	fallback        fs.FS
+	langArchiveURL  string
+	releaseTag      string
+	releaseBaseURL  string
+	useFallback     bool
+	clientVersion   string
+	checksum        string
+	checksumSidecar bool
+	publicKey       *PublicKey
+}
+
+// UpdateResult is a result of Update
+type UpdateResult struct {
+	// SourceURL is the url where the database was downloaded from
+	SourceURL string
+	// NotModified is true if the remote database has not been changed since the last update
+	NotModified bool
+	// AddedPages are paths of pages which are new in the database
+	AddedPages []string
+	// ChangedPages are paths of pages whose content has been changed
+	ChangedPages []string
+	// RemovedPages are paths of pages which were deleted or renamed upstream and removed from the database
+	RemovedPages []string
 }
 
 // New create a instance of tldr repository
 func New(tldrPath string, opts ...Option) *Tldr {
 	t := &Tldr{
-		path:          tldrPath,
-		pageSourceURL: PageSourceURL,
-		platforms:     []Platform{PlatformCommon},
-		languages:     getLanguages(""),
-		update:        false,
+		path:           tldrPath,
+		pageSourceURLs: []string{PageSourceURL},
+		releaseBaseURL: ReleaseBaseURL,
+		downloader:     newDownloader(),
+		platforms:      []Platform{PlatformCommon},
+		languages:      getLanguages(""),
+		update:         false,
+		snapshots:      1,
 	}
 
 	for _, opt := range opts {
@@ -90,55 +214,463 @@ func New(tldrPath string, opts ...Option) *Tldr {
 
 // OnInitialize create and update tldr directory
 func (t *Tldr) OnInitialize(ctx context.Context) error {
-	initUpdate := false
-	if !pathExists(t.path) {
-		if err := os.MkdirAll(t.path, os.ModePerm); err != nil {
-			return fmt.Errorf("failed to create tldr dir: %w", err)
+	if t.customStorage != nil {
+		if fi, err := fs.Stat(t.customStorage, getLangDir(languageCodeEN)); err != nil || !fi.IsDir() {
+			return errors.New("tldr storage does not have a page tree")
 		}
-		// automatically updated if indexfile does not exist
-		initUpdate = true
+		return nil
+	}
+
+	if err := os.MkdirAll(t.path, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create tldr dir: %w", err)
+	}
+	// automatically updated if no database exists.
+	// Note if another process is installing the first database, Update waits for it
+	initUpdate := !t.hasDB()
+	if initUpdate && !t.update && t.fallback != nil {
+		// Note use the fallback without waiting for the download, which is left to an explicit Update
+		t.useFallback = true
+		return nil
+	}
+	if initUpdate && !t.update && t.skipInitUpdate {
+		return nil
 	}
 
 	if t.update || initUpdate {
-		if err := t.Update(ctx); err != nil {
+		if _, err := t.Update(ctx); err != nil {
+			if initUpdate && t.fallback != nil {
+				// Note use the fallback until the first update succeeds
+				t.useFallback = true
+				return nil
+			}
 			return fmt.Errorf("failed to update tldr repository: %w", err)
 		}
 	}
 
-	if f := t.indexFilePath(); !pathExists(f) {
-		return fmt.Errorf("tldr database is broken %s", f)
+	if !t.hasIndexFile() {
+		// repair the index file from the page tree
+		if _, err := t.Reindex(); err != nil {
+			return fmt.Errorf("tldr database is broken %s: %w", t.indexFilePath(), err)
+		}
 	}
 
 	return nil
 }
 
-// Update tldr pages from remote zip file
-func (t *Tldr) Update(ctx context.Context) error {
-	zipPath, err := download(ctx, t.pageSourceURL, t.path, filepath.Base(t.pageSourceURL))
+// Close releases the database opened in zip storage mode
+func (t *Tldr) Close() error {
+	if t.store == nil {
+		return nil
+	}
+	err := t.store.Close()
+	t.store = nil
+	return err
+}
+
+// Update tldr pages from remote zip file.
+// The zip is extracted into a staging directory and swapped in after validation
+// so that readers never see a partial database and the old one survives failures.
+func (t *Tldr) Update(ctx context.Context) (*UpdateResult, error) {
+	if t.langArchiveURL != "" {
+		return t.updateLanguages(ctx)
+	}
+	return t.updateFrom(ctx, t.sourceURLs(), true)
+}
+
+// Install imports the database from a local zip `src` which is a file path or a file url.
+// The zip is validated and extracted in the same way as Update
+func (t *Tldr) Install(ctx context.Context, src string) (*UpdateResult, error) {
+	if _, ok := localPath(src); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotLocalFile, src)
+	}
+	return t.updateFrom(ctx, []string{src}, false)
+}
+
+// updateFrom installs the database from the first available `urls`.
+// If `conditional` is true, the database is not downloaded if it has not been changed
+func (t *Tldr) updateFrom(ctx context.Context, urls []string, conditional bool) (*UpdateResult, error) {
+	unlock, err := t.prepareUpdate(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to download a tldr repository: %w", err)
+		return nil, err
+	}
+	defer unlock()
+
+	var cond *validators
+	current, err := t.Manifest()
+	// Note download the whole zip if the current one does not match the expected checksum, key or storage mode
+	if conditional && err == nil && t.hasIndexFile() &&
+		(t.checksum == "" || t.checksum == current.ArchiveSHA256) &&
+		(t.publicKey == nil || t.publicKey.KeyID() == current.SignatureKeyID) &&
+		t.zipStorage == pathExists(filepath.Join(t.dbPath(), archiveFileName)) {
+		cond = current.validators()
 	}
 
-	err = unzip(ctx, zipPath, t.path)
+	a, err := t.fetch(ctx, urls, cond)
+	if errors.Is(err, errNotModified) {
+		return t.refresh(current)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to unzip a tldr repository: %w", err)
+		return nil, fmt.Errorf("failed to download a tldr repository: %w", err)
+	}
+
+	if err := t.verify(ctx, a); err != nil {
+		return nil, err
 	}
 
+	diff, err := t.install(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	return t.installed(diff, a.URL, a), nil
+}
+
+// refresh updates the checked time of the `current` database as it is the latest
+func (t *Tldr) refresh(current *Manifest) (*UpdateResult, error) {
+	current.CheckedAt = time.Now()
+	current.RolledBack = false
+	if err := writeManifest(t.dbPath(), current); err != nil {
+		return nil, fmt.Errorf("failed to refresh the tldr repository: %w", err)
+	}
+	return &UpdateResult{SourceURL: current.SourceURL, NotModified: true}, nil
+}
+
+// verify verifies the checksum and the signature of the downloaded `a`
+func (t *Tldr) verify(ctx context.Context, a *archive) error {
+	if err := t.verifyChecksum(ctx, a); err != nil {
+		return fmt.Errorf("failed to verify a tldr repository: %w", err)
+	}
+	if err := t.verifySignature(ctx, a); err != nil {
+		return fmt.Errorf("failed to verify a tldr repository: %w", err)
+	}
+	return nil
+}
+
+// installed finishes the update which installed `archives` from `sourceURL`
+func (t *Tldr) installed(diff *pageDiff, sourceURL string, archives ...*archive) *UpdateResult {
+	t.useFallback = false
 	// not remove for troubleshooting when download/update failed
-	_ = os.Remove(zipPath)
+	for _, a := range archives {
+		if a != nil {
+			_ = os.Remove(a.path)
+		}
+	}
+	return diff.result(sourceURL)
+}
+
+// prepareUpdate creates the data dir and takes the lock for updating
+func (t *Tldr) prepareUpdate(ctx context.Context) (unlock func(), err error) {
+	if t.customStorage != nil {
+		return nil, ErrReadOnlyStorage
+	}
+
+	if err := os.MkdirAll(t.path, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create tldr dir: %w", err)
+	}
+	return t.lock(ctx)
+}
+
+// fetch downloads the zip from the first available mirror of `urls`
+func (t *Tldr) fetch(ctx context.Context, urls []string, cond *validators) (*archive, error) {
+	var lastErr error
+	errs := make([]string, 0, len(urls))
+	for _, u := range urls {
+		a, err := t.downloader.downloadWithRetry(ctx, u, t.path, filepath.Base(u), cond)
+		if err == nil || errors.Is(err, errNotModified) {
+			return a, err
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) <= 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("all mirrors failed: %s: %w", strings.Join(errs[:len(errs)-1], ", "), lastErr)
+}
+
+// install extracts the zip into a staging directory and swaps it in.
+// In zip storage mode, the zip is placed into the staging directory instead
+func (t *Tldr) install(ctx context.Context, a *archive) (*pageDiff, error) {
+	return t.stage(func(stagingDir string) (fs.FS, *Manifest, error) {
+		m := t.newManifest(a.URL)
+		m.ArchiveSize = a.size
+		m.ArchiveSHA256 = a.sha256
+		m.ETag = a.ETag
+		m.LastModified = a.LastModified
+
+		if t.zipStorage {
+			zipPath := filepath.Join(stagingDir, archiveFileName)
+			if err := linkOrCopy(a.path, zipPath); err != nil {
+				return nil, nil, fmt.Errorf("failed to place a tldr repository: %w", err)
+			}
+			zs, err := openZipStore(zipPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to open a tldr repository: %w", err)
+			}
+			return zs, m, nil
+		}
+
+		if err := unzip(ctx, a.path, stagingDir); err != nil {
+			return nil, nil, fmt.Errorf("failed to unzip a tldr repository: %w", err)
+		}
+		// Note the index file must be newer than page dirs not to be regarded as outdated
+		now := time.Now()
+		_ = os.Chtimes(filepath.Join(stagingDir, indexFileName), now, now)
+		return os.DirFS(stagingDir), m, nil
+	})
+}
+
+// stage builds a database in a new staging directory by `build` and commits it.
+// The storage returned by `build` is closed after the commit if it is an io.Closer
+func (t *Tldr) stage(build func(stagingDir string) (fs.FS, *Manifest, error)) (*pageDiff, error) {
+	stagingDir, err := os.MkdirTemp(t.path, stagingPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a staging dir: %w", err)
+	}
+	// Note the staging dir has been renamed if the swap succeeded
+	defer os.RemoveAll(stagingDir)
+
+	fsys, m, err := build(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := fsys.(io.Closer); ok {
+		defer c.Close()
+	}
+	return t.commit(stagingDir, fsys, m)
+}
+
+// commit validates the database in `stagingDir`, saves `m` and swaps it in.
+// It returns the differences of pages from the current database
+func (t *Tldr) commit(stagingDir string, fsys fs.FS, m *Manifest) (*pageDiff, error) {
+	if err := validateDB(fsys); err != nil {
+		return nil, fmt.Errorf("downloaded tldr repository is invalid: %w", err)
+	}
+
+	names, err := listFiles(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list a tldr repository: %w", err)
+	}
+
+	m.Pages = countPages(names)
+	if err := writeManifest(stagingDir, m); err != nil {
+		return nil, fmt.Errorf("failed to save a manifest: %w", err)
+	}
+
+	// Note the swap removes pages which are only in the current database
+	diff, ok := t.diffPages(fsys, names)
+	if err := t.swap(stagingDir); err != nil {
+		return nil, fmt.Errorf("failed to install a tldr repository: %w", err)
+	}
+	if ok {
+		// Note the report is informational, the database has been installed anyway
+		_ = t.saveChanges(diff, m.SourceURL)
+	}
+	return diff, nil
+}
+
+// newManifest returns a manifest of a database downloaded now from `sourceURL`
+func (t *Tldr) newManifest(sourceURL string) *Manifest {
+	keyID := ""
+	if t.publicKey != nil {
+		keyID = t.publicKey.KeyID()
+	}
+
+	releaseTag := ""
+	if t.releaseTag != "" && strings.HasPrefix(sourceURL, t.releaseURL("")) {
+		releaseTag = t.releaseTag
+	}
+
+	now := time.Now()
+	return &Manifest{
+		SourceURL:      sourceURL,
+		DownloadedAt:   now,
+		CheckedAt:      now,
+		ClientVersion:  t.clientVersion,
+		SignatureKeyID: keyID,
+		ReleaseTag:     releaseTag,
+		PinnedTag:      t.releaseTag,
+	}
+}
+
+// swap replaces the current database with `newDir` by renaming the current symlink atomically
+func (t *Tldr) swap(newDir string) error {
+	name := dbDirPrefix + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := os.Rename(newDir, filepath.Join(t.path, name)); err != nil {
+		return err
+	}
+
+	if err := t.link(name); err != nil {
+		_ = os.RemoveAll(filepath.Join(t.path, name))
+		return err
+	}
+
+	// Note the zip of the previous database should not be read any more
+	_ = t.Close()
+	t.pruneSnapshots()
+	t.removeLegacyDB()
+	return nil
+}
+
+// removeLegacyDB removes a database extracted into the data dir directly by older versions
+func (t *Tldr) removeLegacyDB() {
+	entries, err := os.ReadDir(t.path)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if name == indexFileName || name == getLangDir(languageCodeEN) ||
+			strings.HasPrefix(name, getLangDir(languageCodeEN)+".") {
+			_ = os.RemoveAll(filepath.Join(t.path, name))
+		}
+	}
+}
+
+// validateDB checks `fsys` has a loadable index file and a page tree
+func validateDB(fsys fs.FS) error {
+	f, err := fsys.Open(indexFileName)
+	if err != nil {
+		return fmt.Errorf("failed to open a index file: %w", err)
+	}
+	defer f.Close()
+
+	cmdIndex := &CmdsIndex{}
+	if err := json.NewDecoder(f).Decode(cmdIndex); err != nil {
+		return fmt.Errorf("failed to parse a index file: %w", err)
+	}
+	if len(cmdIndex.Commands) == 0 {
+		return errors.New("no commands in a index file")
+	}
+
+	pagesDir := getLangDir(languageCodeEN)
+	if fi, err := fs.Stat(fsys, pagesDir); err != nil || !fi.IsDir() {
+		return fmt.Errorf("no page tree in %s", pagesDir)
+	}
 	return nil
 }
 
+// listFiles returns paths of regular files under `root` in `fsys`
+func listFiles(fsys fs.FS, root string) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			names = append(names, path)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// linkOrCopy creates `dst` as a hard link of `src` or a copy if a link is not supported
+func linkOrCopy(src, dst string) (reterr error) {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if werr := w.Close(); werr != nil && reterr == nil {
+			reterr = werr
+		}
+	}()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// storage returns the page tree in use.
+// The database is read from the zip if it is installed in zip storage mode
+func (t *Tldr) storage() (fs.FS, error) {
+	if t.customStorage != nil {
+		return t.customStorage, nil
+	}
+	if t.useFallback {
+		return t.fallback, nil
+	}
+	if t.store != nil {
+		return t.store, nil
+	}
+
+	zipPath := filepath.Join(t.dbPath(), archiveFileName)
+	if pathExists(zipPath) {
+		zs, err := openZipStore(zipPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open a tldr repository: %w", err)
+		}
+		t.store = zs
+		return zs, nil
+	}
+	return os.DirFS(t.dbPath()), nil
+}
+
+// openFile opens `name` in the page tree in use
+func (t *Tldr) openFile(name string) (fs.File, error) {
+	fsys, err := t.storage()
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open(name)
+}
+
+// openIndexFile opens the index file.
+// The index file rebuilt in zip storage mode precedes the one in the zip
+func (t *Tldr) openIndexFile() (fs.File, error) {
+	if t.customStorage == nil && !t.useFallback {
+		if f, err := os.Open(filepath.Join(t.dbPath(), indexFileName)); err == nil {
+			return f, nil
+		}
+	}
+	return t.openFile(indexFileName)
+}
+
+// hasIndexFile returns true if the page tree in use has a index file
+func (t *Tldr) hasIndexFile() bool {
+	f, err := t.openIndexFile()
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// openPage opens `name` in the overlays first, then in the page tree in use
+func (t *Tldr) openPage(name string) (fs.File, error) {
+	for _, o := range t.overlays {
+		f, err := o.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrInvalid) {
+			return nil, err
+		}
+	}
+	return t.openFile(name)
+}
+
 // FindPage find tldr page by `cmds`
 func (t *Tldr) FindPage(cmds []string) (*Page, error) {
 	page := strings.Join(cmds, "-") + ".md"
 	for _, ptDir := range t.platforms {
 		for _, lang := range t.languages {
-			path := filepath.Join(t.path, getLangDir(lang), ptDir.String(), page)
-
-			f, err := os.Open(path)
+			f, err := t.openPage(path.Join(getLangDir(lang), ptDir.String(), page))
 			if err != nil {
-				if errors.Is(err, os.ErrNotExist) {
+				if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
 					// if cmd does not exist, try to find it in next platform/language
 					continue
 				}
@@ -153,8 +685,22 @@ func (t *Tldr) FindPage(cmds []string) (*Page, error) {
 	return &Page{}, fmt.Errorf("failed to find %s: %w", page, ErrNotFoundPage)
 }
 
+// Languages returns languages to look up pages in priority order
+func (t *Tldr) Languages() []string {
+	return append([]string{}, t.languages...)
+}
+
 // Expired return true if tldr repository have passed `ttl`
 func (t *Tldr) Expired(ttl time.Duration) bool {
+	if m, err := t.Manifest(); err == nil {
+		return m.Age() > ttl
+	}
+	if t.customStorage != nil {
+		// Note a read-only storage cannot be updated
+		return false
+	}
+
+	// Note older versions do not have a manifest
 	age, err := age(t.indexFilePath())
 	if err != nil {
 		return true
@@ -163,8 +709,32 @@ func (t *Tldr) Expired(ttl time.Duration) bool {
 	return age > ttl
 }
 
+// ConfigChanged returns true if the database was installed for other languages than the configured ones
+// or while another release was pinned.
+// The database is not updated automatically, so call Update in background or by a user operation
+func (t *Tldr) ConfigChanged() bool {
+	return t.languagesMissing() || t.releaseChanged()
+}
+
+// hasDB returns true if the data dir has a database including one of older versions
+func (t *Tldr) hasDB() bool {
+	return pathExists(filepath.Join(t.path, currentDBName)) ||
+		pathExists(filepath.Join(t.path, indexFileName)) ||
+		pathExists(filepath.Join(t.path, getLangDir(languageCodeEN)))
+}
+
 func (t *Tldr) indexFilePath() string {
-	return filepath.Join(t.path, "index.json")
+	return filepath.Join(t.dbPath(), indexFileName)
+}
+
+// dbPath return the directory of the database in use
+func (t *Tldr) dbPath() string {
+	current := filepath.Join(t.path, currentDBName)
+	if pathExists(current) {
+		return current
+	}
+	// Note older versions extracted the database into the data dir directly
+	return t.path
 }
 
 // age return the time since the data exist at
@@ -177,6 +747,20 @@ func age(path string) (time.Duration, error) {
 	return time.Since(fi.ModTime()), nil
 }
 
+// writeJSON writes `v` to `path` via a temporary file
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 // pathExists return true if path exists
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
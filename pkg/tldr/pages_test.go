@@ -2,11 +2,17 @@ package tldr
 
 import (
 	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	tldrtest "github.com/konoui/alfred-tldr/pkg/tldr/test"
 )
 
@@ -118,7 +124,7 @@ func TestUpdate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			i := New(tt.args.tldrPath, tt.args.opts...)
-			err := i.Update(context.TODO())
+			_, err := i.Update(context.TODO())
 			if tt.expectErr && err == nil {
 				t.Errorf("expect error happens, but got response")
 			}
@@ -217,3 +223,372 @@ func TestExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateKeepsDatabaseOnFailure(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"))
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid url returns a non-zip body
+	broken := New(tldrPath, WithTestInvalidURL(), WithLanguage("en"))
+	if _, err := broken.Update(context.TODO()); err == nil {
+		t.Fatal("expect error happens, but got response")
+	}
+
+	if _, err := broken.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("unexpected error got: %+v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(tldrPath, stagingPrefix+"*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("staging dirs remain: %v", matches)
+	}
+}
+
+func TestUpdateMigratesLegacyDatabase(t *testing.T) {
+	tldrPath := t.TempDir()
+	// emulate a database extracted by older versions
+	legacyPage := filepath.Join(tldrPath, "pages", "common", "legacy-only.md")
+	if err := os.MkdirAll(filepath.Dir(legacyPage), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyPage, []byte("# legacy-only\n\n> legacy\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tldrPath, indexFileName), []byte(`{"commands":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"))
+	if _, err := tldr.FindPage([]string{"legacy-only"}); err != nil {
+		t.Fatalf("legacy database should be readable: %+v", err)
+	}
+
+	if _, err := tldr.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tldr.FindPage([]string{"legacy-only"}); err == nil {
+		t.Errorf("expect legacy page to be removed")
+	}
+	if pathExists(legacyPage) {
+		t.Errorf("legacy page remains: %s", legacyPage)
+	}
+	if _, err := tldr.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("unexpected error got: %+v", err)
+	}
+}
+
+func TestUpdateNotModified(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithTestZipURL())
+	if _, err := tldr.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	prev, err := os.Readlink(filepath.Join(tldrPath, currentDBName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := tldr.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.CheckedAt = time.Now().Add(-24 * time.Hour)
+	if err := writeManifest(tldr.dbPath(), m); err != nil {
+		t.Fatal(err)
+	}
+	if !tldr.Expired(time.Hour) {
+		t.Fatal("expect the database to be expired")
+	}
+
+	if _, err := tldr.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.Readlink(filepath.Join(tldrPath, currentDBName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != prev {
+		t.Errorf("not modified database should not be replaced: want: %s, got: %s", prev, got)
+	}
+	if tldr.Expired(time.Hour) {
+		t.Errorf("not modified update should refresh the database age")
+	}
+}
+
+func TestUpdateWithMirrors(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(down.Close)
+
+	tests := []struct {
+		name      string
+		urls      []string
+		want      string
+		expectErr bool
+	}{
+		{
+			name: "fall back to the next mirror",
+			urls: []string{down.URL + "/tldr.zip", testServer.TldrZipURL()},
+			want: testServer.TldrZipURL(),
+		},
+		{
+			name: "use the first mirror",
+			urls: []string{testServer.TldrZipURL(), down.URL + "/tldr.zip"},
+			want: testServer.TldrZipURL(),
+		},
+		{
+			name:      "all mirrors failed",
+			urls:      []string{down.URL + "/a/tldr.zip", down.URL + "/b/tldr.zip"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tldr := New(filepath.Join(t.TempDir(), ".tldr"), WithRepositoryURLs(tt.urls...))
+			res, err := tldr.Update(context.TODO())
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expect error happens, but got response")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error got: %+v", err)
+			}
+			if res.SourceURL != tt.want {
+				t.Errorf("want: %s, got: %s", tt.want, res.SourceURL)
+			}
+			m, err := tldr.Manifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.SourceURL != tt.want {
+				t.Errorf("manifest want: %s, got: %s", tt.want, m.SourceURL)
+			}
+		})
+	}
+}
+
+type countingTransport struct {
+	count int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.count, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestUpdateWithHTTPOptions(t *testing.T) {
+	const token = "Bearer secret"
+	var leaked int32
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "" {
+			atomic.AddInt32(&leaked, 1)
+		}
+		http.Redirect(w, r, testServer.TldrZipURL(), http.StatusFound)
+	}))
+	t.Cleanup(public.Close)
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != token || r.Header.Get("X-Token") != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, public.URL+"/tldr.zip", http.StatusFound)
+	}))
+	t.Cleanup(private.Close)
+	privateHost := private.Listener.Addr().String()
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		expectErr bool
+	}{
+		{
+			name: "send the header",
+			opts: []Option{
+				WithHTTPHeader(privateHost, "Authorization", token),
+				WithHTTPHeader(privateHost, "X-Token", token),
+			},
+		},
+		{
+			name: "header of another host",
+			opts: []Option{
+				WithHTTPHeader("tldr.example.com", "Authorization", token),
+				WithHTTPHeader("tldr.example.com", "X-Token", token),
+			},
+			expectErr: true,
+		},
+		{
+			name:      "no header",
+			opts:      []Option{},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &countingTransport{}
+			opts := append([]Option{
+				WithRepositoryURL(private.URL + "/tldr.zip"),
+				WithHTTPClient(&http.Client{Transport: transport}),
+			}, tt.opts...)
+			tldr := New(filepath.Join(t.TempDir(), ".tldr"), opts...)
+			_, err := tldr.Update(context.TODO())
+			if tt.expectErr && err == nil {
+				t.Errorf("expect error happens, but got response")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error got: %+v", err)
+			}
+			if atomic.LoadInt32(&transport.count) == 0 {
+				t.Errorf("the http client is not used")
+			}
+			if atomic.LoadInt32(&leaked) != 0 {
+				t.Errorf("the header is sent to another host on redirect")
+			}
+		})
+	}
+}
+
+func TestUpdateRemovesStalePages(t *testing.T) {
+	oldZip := writeTestZip(t, []zipEntry{
+		{name: indexFileName, body: testIndex},
+		{name: "pages/common/tar.md", body: "# tar"},
+		{name: "pages/common/old-name.md", body: "# old-name"},
+		{name: "pages/sunos/deleted.md", body: "# deleted"},
+	})
+	newZip := writeTestZip(t, []zipEntry{
+		{name: indexFileName, body: testIndex},
+		{name: "pages/common/tar.md", body: "# tar"},
+		{name: "pages/common/new-name.md", body: "# new-name"},
+	})
+
+	for _, mode := range storageModes {
+		t.Run(mode.name, func(t *testing.T) {
+			srv := newSwappableZipServer(t)
+			srv.serve(oldZip)
+
+			opts := append([]Option{WithRepositoryURL(srv.URL), WithForceUpdate()}, mode.opts...)
+			tldr := New(filepath.Join(t.TempDir(), ".tldr"), opts...)
+			t.Cleanup(func() { tldr.Close() })
+			res, err := tldr.Update(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.RemovedPages) != 0 {
+				t.Errorf("no pages should be removed on the first update: %v", res.RemovedPages)
+			}
+
+			srv.serve(newZip)
+			res, err = tldr.Update(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"pages/common/old-name.md", "pages/sunos/deleted.md"}
+			if diff := cmp.Diff(want, res.RemovedPages); diff != "" {
+				t.Errorf("-want +got\n%s", diff)
+			}
+
+			tldr.platforms = []Platform{PlatformSunos, PlatformCommon}
+			for _, cmd := range []string{"old-name", "deleted"} {
+				if _, err := tldr.FindPage([]string{cmd}); !errors.Is(err, ErrNotFoundPage) {
+					t.Errorf("%s: want: %v, got: %v", cmd, ErrNotFoundPage, err)
+				}
+			}
+			if pathExists(filepath.Join(tldr.dbPath(), "pages", "sunos")) {
+				t.Errorf("empty dir remains")
+			}
+		})
+	}
+}
+
+func TestInstall(t *testing.T) {
+	zipPath := testServer.TldrZipPath()
+	tests := []struct {
+		name    string
+		src     string
+		wantErr error
+	}{
+		{
+			name: "plain path",
+			src:  zipPath,
+		},
+		{
+			name: "file url",
+			src:  "file://" + filepath.ToSlash(zipPath),
+		},
+		{
+			name:    "http url is not a local file",
+			src:     testServer.TldrZipURL(),
+			wantErr: ErrNotLocalFile,
+		},
+		{
+			name:    "no such file",
+			src:     filepath.Join(t.TempDir(), "tldr.zip"),
+			wantErr: fs.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tldrPath := filepath.Join(t.TempDir(), ".tldr")
+			tldr := New(tldrPath, WithTestInvalidURL())
+			got, err := tldr.Install(context.TODO(), tt.src)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("want: %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.SourceURL != tt.src {
+				t.Errorf("want: %s, got: %s", tt.src, got.SourceURL)
+			}
+			if !pathExists(zipPath) {
+				t.Fatalf("the source zip should not be removed")
+			}
+			if _, err := tldr.FindPage([]string{"lsof"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("invalid zip keeps the current database", func(t *testing.T) {
+		tldrPath := filepath.Join(t.TempDir(), ".tldr")
+		tldr := New(tldrPath)
+		if _, err := tldr.Install(context.TODO(), zipPath); err != nil {
+			t.Fatal(err)
+		}
+		invalid := filepath.Join(t.TempDir(), "tldr.zip")
+		if err := os.WriteFile(invalid, []byte("not a zip"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tldr.Install(context.TODO(), invalid); err == nil {
+			t.Errorf("expect error happens")
+		}
+		if _, err := tldr.FindPage([]string{"lsof"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestUpdateFromLocalFile(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithRepositoryURL("file://"+filepath.ToSlash(testServer.TldrZipPath())))
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tldr.Update(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.NotModified {
+		t.Errorf("unchanged local file should not be installed again")
+	}
+}
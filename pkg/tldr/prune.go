@@ -0,0 +1,137 @@
+package tldr
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Usage is the disk usage of pages in a language and platform
+type Usage struct {
+	Language string
+	Platform Platform
+	Pages    int
+	// Bytes is the total size of the pages
+	Bytes int64
+}
+
+// DiskUsage returns the disk usage of pages per language and platform sorted by language and platform
+func (t *Tldr) DiskUsage() ([]Usage, error) {
+	fsys, err := t.storage()
+	if err != nil {
+		return nil, err
+	}
+	names, err := listPages(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+
+	type key struct {
+		lang string
+		pt   Platform
+	}
+	usages := make(map[key]*Usage)
+	for _, name := range names {
+		lang, pt, _, ok := parsePagePath(name)
+		if !ok {
+			continue
+		}
+		fi, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		k := key{lang: lang, pt: pt}
+		if usages[k] == nil {
+			usages[k] = &Usage{Language: lang, Platform: pt}
+		}
+		usages[k].Pages++
+		usages[k].Bytes += fi.Size()
+	}
+
+	ret := make([]Usage, 0, len(usages))
+	for _, u := range usages {
+		ret = append(ret, *u)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Language != ret[j].Language {
+			return ret[i].Language < ret[j].Language
+		}
+		return ret[i].Platform < ret[j].Platform
+	})
+	return ret, nil
+}
+
+// PruneTargets returns installed languages which are not in `keep`.
+// English is always kept and an empty `keep` means the languages from the environment
+func (t *Tldr) PruneTargets(keep ...string) ([]string, error) {
+	if len(keep) == 0 {
+		keep = t.archiveLanguages()
+	}
+	fsys, err := t.storage()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	for _, e := range entries {
+		lang, ok := langFromDir(e.Name())
+		if !ok || !e.IsDir() || lang == languageCodeEN || contains(lang, keep) {
+			continue
+		}
+		targets = append(targets, lang)
+	}
+	return targets, nil
+}
+
+// PruneLanguages removes the pages of languages returned by PruneTargets from the current database
+// and rebuilds the index file. It returns the removed languages.
+// Note the next Update installs all languages of the downloaded database again
+func (t *Tldr) PruneLanguages(keep ...string) ([]string, error) {
+	if t.customStorage != nil || t.useFallback {
+		return nil, ErrReadOnlyStorage
+	}
+	if pathExists(filepath.Join(t.dbPath(), archiveFileName)) {
+		return nil, fmt.Errorf("%w: pages in zip storage mode cannot be pruned", ErrReadOnlyStorage)
+	}
+
+	unlock, err := t.lock(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	targets, err := t.PruneTargets(keep...)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	m, merr := t.Manifest()
+	for _, lang := range targets {
+		if err := os.RemoveAll(filepath.Join(t.dbPath(), getLangDir(lang))); err != nil {
+			return nil, fmt.Errorf("failed to remove pages of %s: %w", lang, err)
+		}
+		if merr == nil {
+			m.removeLanguage(lang)
+		}
+	}
+	if merr == nil {
+		if err := writeManifest(t.dbPath(), m); err != nil {
+			return nil, fmt.Errorf("failed to save a manifest: %w", err)
+		}
+	}
+
+	if _, err := t.reindex(); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
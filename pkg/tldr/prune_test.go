@@ -0,0 +1,104 @@
+package tldr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPruneLanguages(t *testing.T) {
+	t.Setenv("LANG", "ja_JP.UTF-8")
+	t.Setenv("LANGUAGE", "")
+	entries := []zipEntry{
+		{name: "pages/common/tar.md", body: "# tar\n"},
+		{name: "pages/linux/ls.md", body: "# ls\n"},
+		{name: "pages.ja/common/tar.md", body: "# tar ja\n"},
+		{name: "pages.fr/common/tar.md", body: "# tar fr\n"},
+		{name: "pages.fr/linux/ls.md", body: "# ls fr\n"},
+		{name: "pages.de/common/tar.md", body: "# tar de\n"},
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.name)
+	}
+	index, err := json.Marshal(buildIndex(names))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipPath := writeTestZip(t, append(entries, zipEntry{name: indexFileName, body: string(index)}))
+
+	// Note a language selected by WithLanguage is not kept
+	tldr := New(filepath.Join(t.TempDir(), ".tldr"), WithLanguage("de"))
+	if _, err := tldr.Install(context.TODO(), zipPath); err != nil {
+		t.Fatal(err)
+	}
+
+	usages, err := tldr.DiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Usage{
+		{Language: "de", Platform: PlatformCommon, Pages: 1, Bytes: 9},
+		{Language: "en", Platform: PlatformCommon, Pages: 1, Bytes: 6},
+		{Language: "en", Platform: PlatformLinux, Pages: 1, Bytes: 5},
+		{Language: "fr", Platform: PlatformCommon, Pages: 1, Bytes: 9},
+		{Language: "fr", Platform: PlatformLinux, Pages: 1, Bytes: 8},
+		{Language: "ja", Platform: PlatformCommon, Pages: 1, Bytes: 9},
+	}
+	if diff := cmp.Diff(want, usages); diff != "" {
+		t.Errorf("-want +got\n%s", diff)
+	}
+
+	targets, err := tldr.PruneTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"de", "fr"}, targets); diff != "" {
+		t.Errorf("configured languages should be kept -want +got\n%s", diff)
+	}
+
+	removed, err := tldr.PruneLanguages("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"fr", "ja"}, removed); diff != "" {
+		t.Errorf("-want +got\n%s", diff)
+	}
+
+	index2, err := tldr.LoadIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range index2.Commands {
+		for _, lang := range cmd.Languages {
+			if lang != "en" && lang != "de" {
+				t.Errorf("removed language %s remains in the index: %+v", lang, cmd)
+			}
+		}
+	}
+	m, err := tldr.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Pages["fr"]; ok {
+		t.Errorf("removed language remains in the manifest: %+v", m.Pages)
+	}
+	if _, err := New(tldr.path, WithLanguage("de")).FindPage([]string{"tar"}); err != nil {
+		t.Errorf("kept language should be found: %v", err)
+	}
+
+	t.Run("zip storage cannot be pruned", func(t *testing.T) {
+		zipTldr := New(filepath.Join(t.TempDir(), ".tldr"), WithZipStorage())
+		t.Cleanup(func() { zipTldr.Close() })
+		if _, err := zipTldr.Install(context.TODO(), zipPath); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := zipTldr.PruneLanguages(); !errors.Is(err, ErrReadOnlyStorage) {
+			t.Errorf("want: %v, got: %v", ErrReadOnlyStorage, err)
+		}
+	})
+}
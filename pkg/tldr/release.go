@@ -0,0 +1,57 @@
+package tldr
+
+import (
+	"strings"
+)
+
+// ReleaseBaseURL is the default base url of tldr-pages release assets
+const ReleaseBaseURL = "https://github.com/tldr-pages/tldr/releases/download"
+
+// WithReleaseTag pins the database to the tldr-pages release of `tag`, e.g.) v2.0.
+// Update downloads the archive of the release instead of the latest one or mirrors
+func WithReleaseTag(tag string) Option {
+	return func(t *Tldr) {
+		t.releaseTag = strings.TrimSpace(tag)
+	}
+}
+
+// WithReleaseBaseURL replaces ReleaseBaseURL
+// This is useful for local test
+func WithReleaseBaseURL(u string) Option {
+	return func(t *Tldr) {
+		if u != "" {
+			t.releaseBaseURL = strings.TrimSuffix(u, "/")
+		}
+	}
+}
+
+// releaseURL returns the url of `filename` in the pinned release
+func (t *Tldr) releaseURL(filename string) string {
+	return t.releaseBaseURL + "/" + t.releaseTag + "/" + filename
+}
+
+// sourceURLs returns urls of the whole database
+func (t *Tldr) sourceURLs() []string {
+	if t.releaseTag != "" {
+		return []string{t.releaseURL(archiveFileName)}
+	}
+	return t.pageSourceURLs
+}
+
+// languageArchiveURL returns the url template of per-language archives.
+// The default template is replaced with the one of the pinned release
+func (t *Tldr) languageArchiveURL() string {
+	if t.releaseTag != "" && t.langArchiveURL == LanguageArchiveURL {
+		return t.releaseURL("tldr-pages." + languagePlaceholder + ".zip")
+	}
+	return t.langArchiveURL
+}
+
+// releaseChanged returns true if the database was installed while another release or no release was pinned
+func (t *Tldr) releaseChanged() bool {
+	if t.releaseTag == "" || t.customStorage != nil {
+		return false
+	}
+	m, err := t.Manifest()
+	return err == nil && m.PinnedTag != t.releaseTag
+}
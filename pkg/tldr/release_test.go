@@ -0,0 +1,99 @@
+package tldr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReleaseTag(t *testing.T) {
+	zip, err := os.ReadFile(testServer.TldrZipPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/v2.0/tldr.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(zip)
+	}))
+	t.Cleanup(srv.Close)
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+
+	t.Run("download the pinned release", func(t *testing.T) {
+		tldr := New(tldrPath, WithTestInvalidURL(), WithReleaseTag("v2.0"), WithReleaseBaseURL(srv.URL+"/"))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		m, err := tldr.Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ReleaseTag != "v2.0" || m.SourceURL != srv.URL+"/v2.0/tldr.zip" {
+			t.Errorf("unexpected manifest: %+v", m)
+		}
+	})
+
+	t.Run("keep the current database if the pinned release is not available", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		tldr := New(tldrPath, WithReleaseTag("v3.0"), WithReleaseBaseURL(srv.URL))
+		if err := tldr.OnInitialize(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt32(&requests); n != 0 {
+			t.Errorf("the release should not be fetched on initialize: %d requests", n)
+		}
+		if !tldr.ConfigChanged() {
+			t.Errorf("want: config changed, got: not changed")
+		}
+		if _, err := tldr.Update(context.TODO()); err == nil {
+			t.Errorf("expect error happens")
+		}
+		m, err := tldr.Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ReleaseTag != "v2.0" {
+			t.Errorf("want: v2.0, got: %s", m.ReleaseTag)
+		}
+	})
+
+	t.Run("an installed database is regarded as pinned", func(t *testing.T) {
+		tldrPath := filepath.Join(t.TempDir(), ".tldr")
+		tldr := New(tldrPath, WithReleaseTag("v2.0"), WithReleaseBaseURL(srv.URL))
+		if _, err := tldr.Install(context.TODO(), testServer.TldrZipPath()); err != nil {
+			t.Fatal(err)
+		}
+		if tldr.ConfigChanged() {
+			t.Errorf("an installed database should not be fetched again")
+		}
+		m, err := tldr.Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ReleaseTag != "" || m.PinnedTag != "v2.0" {
+			t.Errorf("unexpected manifest: %+v", m)
+		}
+	})
+
+	t.Run("unpin the release", func(t *testing.T) {
+		tldr := New(tldrPath, WithTestZipURL())
+		if _, err := tldr.Update(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		m, err := tldr.Manifest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.ReleaseTag != "" {
+			t.Errorf("want: no release tag, got: %s", m.ReleaseTag)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package tldr
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	signatureSuffix       = ".sig"
+	trustedCommentPrefix  = "trusted comment: "
+	untrustedCommentLabel = "untrusted comment:"
+)
+
+// signature algorithms of minisign
+var (
+	sigAlgEd        = []byte("Ed")
+	sigAlgPrehashed = []byte("ED")
+)
+
+var (
+	ErrSignatureMissing = errors.New("signature missing")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// PublicKey is a minisign compatible Ed25519 public key
+type PublicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// KeyID returns the key id as a hex string like minisign
+func (pk *PublicKey) KeyID() string {
+	id := pk.keyID
+	// Note minisign shows the key id in big endian
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id[:]))
+}
+
+// ParsePublicKey parses a minisign public key.
+// Both a base64 line and a content of the public key file with an untrusted comment are accepted
+func ParsePublicKey(s string) (*PublicKey, error) {
+	var line string
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, untrustedCommentLabel) {
+			continue
+		}
+		line = l
+		break
+	}
+
+	data, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode a public key: %w", err)
+	}
+	if len(data) != 2+8+ed25519.PublicKeySize || !bytes.Equal(data[:2], sigAlgEd) {
+		return nil, errors.New("unsupported public key format")
+	}
+
+	pk := &PublicKey{key: ed25519.PublicKey(data[10:])}
+	copy(pk.keyID[:], data[2:10])
+	return pk, nil
+}
+
+// WithPublicKey requires a detached signature signed by `pk` next to the zip.
+// The signature is fetched from the url of the zip with `.sig` suffix
+func WithPublicKey(pk *PublicKey) Option {
+	return func(t *Tldr) {
+		t.publicKey = pk
+	}
+}
+
+// verifySignature returns an error if the zip is unsigned or badly signed
+func (t *Tldr) verifySignature(ctx context.Context, a *archive) error {
+	if t.publicKey == nil {
+		return nil
+	}
+
+	data, err := t.downloader.fetch(ctx, a.URL+signatureSuffix)
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %s", ErrSignatureMissing, a.URL+signatureSuffix)
+		}
+		return fmt.Errorf("failed to fetch a signature: %w", err)
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return verifyMinisign(t.publicKey, f, data)
+}
+
+// verifyMinisign verifies `r` with a minisign signature file `sigFile`
+func verifyMinisign(pk *PublicKey, r io.Reader, sigFile []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(sigFile)), "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("%w: unexpected format", ErrInvalidSignature)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("%w: failed to decode a signature", ErrInvalidSignature)
+	}
+	if !bytes.Equal(sig[2:10], pk.keyID[:]) {
+		return fmt.Errorf("%w: signed by another key", ErrInvalidSignature)
+	}
+
+	var message []byte
+	switch {
+	case bytes.Equal(sig[:2], sigAlgEd):
+		message, err = io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+	case bytes.Equal(sig[:2], sigAlgPrehashed):
+		h, _ := blake2b.New512(nil)
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+		message = h.Sum(nil)
+	default:
+		return fmt.Errorf("%w: unsupported algorithm", ErrInvalidSignature)
+	}
+	if !ed25519.Verify(pk.key, message, sig[10:]) {
+		return fmt.Errorf("%w: signature verification failed", ErrInvalidSignature)
+	}
+
+	trustedComment := strings.TrimSpace(lines[2])
+	if !strings.HasPrefix(trustedComment, trustedCommentPrefix) {
+		return fmt.Errorf("%w: no trusted comment", ErrInvalidSignature)
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: failed to decode a global signature", ErrInvalidSignature)
+	}
+	// Note the global signature covers the signature and the trusted comment
+	global := append(append([]byte{}, sig[10:]...), strings.TrimPrefix(trustedComment, trustedCommentPrefix)...)
+	if !ed25519.Verify(pk.key, global, globalSig) {
+		return fmt.Errorf("%w: trusted comment verification failed", ErrInvalidSignature)
+	}
+	return nil
+}
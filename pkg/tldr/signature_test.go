@@ -0,0 +1,222 @@
+package tldr
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+type testSigner struct {
+	keyID [8]byte
+	priv  ed25519.PrivateKey
+	pub   ed25519.PublicKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSigner{priv: priv, pub: pub}
+	if _, err := rand.Read(s.keyID[:]); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// publicKey returns a content of a minisign public key file
+func (s *testSigner) publicKey() string {
+	data := append(append([]byte("Ed"), s.keyID[:]...), s.pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(data) + "\n"
+}
+
+// sign returns a content of a minisign signature file
+func (s *testSigner) sign(message []byte, prehashed bool) string {
+	alg := []byte("Ed")
+	if prehashed {
+		alg = []byte("ED")
+		sum := blake2b.Sum512(message)
+		message = sum[:]
+	}
+	sig := ed25519.Sign(s.priv, message)
+	comment := "timestamp:1700000000\tfile:tldr.zip"
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), comment...))
+	return fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append(alg, s.keyID[:]...), sig...)),
+		comment,
+		base64.StdEncoding.EncodeToString(global),
+	)
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+	pk, err := ParsePublicKey(signer.publicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("tldr pages")
+
+	tests := []struct {
+		name    string
+		message []byte
+		sig     string
+		wantErr error
+	}{
+		{
+			name:    "legacy signature",
+			message: message,
+			sig:     signer.sign(message, false),
+		},
+		{
+			name:    "prehashed signature",
+			message: message,
+			sig:     signer.sign(message, true),
+		},
+		{
+			name:    "tampered message",
+			message: []byte("tampered"),
+			sig:     signer.sign(message, true),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "signed by another key",
+			message: message,
+			sig:     other.sign(message, true),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered trusted comment",
+			message: message,
+			sig:     strings.Replace(signer.sign(message, true), "tldr.zip", "evil.zip", 1),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "broken signature file",
+			message: message,
+			sig:     "untrusted comment: \n",
+			wantErr: ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyMinisign(pk, bytes.NewReader(tt.message), []byte(tt.sig))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("want: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	signer := newTestSigner(t)
+	base64Line := strings.Split(signer.publicKey(), "\n")[1]
+	for name, s := range map[string]string{
+		"file content": signer.publicKey(),
+		"base64 line":  base64Line,
+	} {
+		t.Run(name, func(t *testing.T) {
+			pk, err := ParsePublicKey(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pk.key, signer.pub) || pk.keyID != signer.keyID {
+				t.Errorf("unexpected public key: %+v", pk)
+			}
+		})
+	}
+
+	if _, err := ParsePublicKey("invalid"); err == nil {
+		t.Errorf("expect error happens, but got response")
+	}
+}
+
+func TestUpdateWithSignature(t *testing.T) {
+	signer := newTestSigner(t)
+	pk, err := ParsePublicKey(signer.publicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(testServer.TldrZipURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipData, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newSource := func(sig string) string {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if filepath.Ext(r.URL.Path) == signatureSuffix {
+				if sig == "" {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprint(w, sig)
+				return
+			}
+			http.Redirect(w, r, testServer.TldrZipURL(), http.StatusFound)
+		}))
+		t.Cleanup(ts.Close)
+		return ts.URL + "/tldr.zip"
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{
+			name: "signed archive",
+			url:  newSource(signer.sign(zipData, true)),
+		},
+		{
+			name:    "unsigned archive",
+			url:     newSource(""),
+			wantErr: ErrSignatureMissing,
+		},
+		{
+			name:    "badly signed archive",
+			url:     newSource(signer.sign([]byte("other"), true)),
+			wantErr: ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tldr := New(filepath.Join(t.TempDir(), ".tldr"), WithRepositoryURL(tt.url), WithPublicKey(pk))
+			_, err := tldr.Update(context.TODO())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want: %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if pathExists(tldr.indexFilePath()) {
+					t.Errorf("unverified archive should not be installed")
+				}
+				return
+			}
+			m, err := tldr.Manifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.SignatureKeyID != pk.KeyID() {
+				t.Errorf("want: %s, got: %s", pk.KeyID(), m.SignatureKeyID)
+			}
+		})
+	}
+}
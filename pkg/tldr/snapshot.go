@@ -0,0 +1,176 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrSnapshotNotFound = errors.New("no snapshot found")
+
+// WithSnapshots keeps the last `n` databases including the current one to roll back
+func WithSnapshots(n int) Option {
+	return func(t *Tldr) {
+		if n > 0 {
+			t.snapshots = n
+		}
+	}
+}
+
+// Snapshot is a database kept in the data dir
+type Snapshot struct {
+	// ID identifies the snapshot for Rollback
+	ID string
+	// DownloadedAt is zero if the snapshot does not have a manifest
+	DownloadedAt time.Time
+	SourceURL    string
+	// Current is true if the snapshot is in use
+	Current bool
+}
+
+// Snapshots returns snapshots in the data dir, newest first
+func (t *Tldr) Snapshots() ([]*Snapshot, error) {
+	ids, err := t.snapshotIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := os.Readlink(filepath.Join(t.path, currentDBName))
+	snapshots := make([]*Snapshot, 0, len(ids))
+	for _, id := range ids {
+		s := &Snapshot{ID: id, Current: id == current}
+		if m, err := readManifest(os.DirFS(filepath.Join(t.path, id))); err == nil {
+			s.DownloadedAt = m.DownloadedAt
+			s.SourceURL = m.SourceURL
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// Rollback makes the snapshot of `id` current
+func (t *Tldr) Rollback(id string) error {
+	if t.customStorage != nil {
+		return ErrReadOnlyStorage
+	}
+	if _, ok := snapshotTime(id); !ok {
+		return fmt.Errorf("%w: %s", ErrSnapshotNotFound, id)
+	}
+
+	unlock, err := t.lock(context.Background())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dir := filepath.Join(t.path, id)
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return fmt.Errorf("%w: %s", ErrSnapshotNotFound, id)
+	}
+
+	var fsys fs.FS = os.DirFS(dir)
+	if zipPath := filepath.Join(dir, archiveFileName); pathExists(zipPath) {
+		zs, err := openZipStore(zipPath)
+		if err != nil {
+			return fmt.Errorf("failed to open the snapshot: %w", err)
+		}
+		defer zs.Close()
+		fsys = zs
+	}
+	if err := validateDB(fsys); err != nil {
+		return fmt.Errorf("the snapshot is invalid: %w", err)
+	}
+
+	// Note mark the snapshot not to be replaced by an automatic update
+	if m, err := readManifest(os.DirFS(dir)); err == nil && !m.RolledBack {
+		m.RolledBack = true
+		if err := writeManifest(dir, m); err != nil {
+			return fmt.Errorf("failed to save a manifest: %w", err)
+		}
+	}
+
+	if err := t.link(id); err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+	// Note the zip of the previous database should not be read any more
+	_ = t.Close()
+	return nil
+}
+
+// RolledBack returns true if the current database was rolled back and has not been updated since.
+// The database should not be replaced by an automatic update
+func (t *Tldr) RolledBack() bool {
+	m, err := t.Manifest()
+	return err == nil && m.RolledBack
+}
+
+// link points the current symlink to `name` atomically
+func (t *Tldr) link(name string) error {
+	// Note a relative target keeps the data dir relocatable
+	tmpLink := filepath.Join(t.path, stagingPrefix+name)
+	if err := os.Symlink(name, tmpLink); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpLink, filepath.Join(t.path, currentDBName)); err != nil {
+		_ = os.Remove(tmpLink)
+		return err
+	}
+	return nil
+}
+
+// pruneSnapshots removes snapshots except the newest ones and the current one
+func (t *Tldr) pruneSnapshots() {
+	ids, err := t.snapshotIDs()
+	if err != nil {
+		return
+	}
+	current, _ := os.Readlink(filepath.Join(t.path, currentDBName))
+	keep := t.snapshots
+	for _, id := range ids {
+		if keep > 0 || id == current {
+			keep--
+			continue
+		}
+		_ = os.RemoveAll(filepath.Join(t.path, id))
+	}
+}
+
+// snapshotIDs returns names of database dirs, newest first
+func (t *Tldr) snapshotIDs() ([]string, error) {
+	entries, err := os.ReadDir(t.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if _, ok := snapshotTime(e.Name()); ok && e.IsDir() {
+			ids = append(ids, e.Name())
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, _ := snapshotTime(ids[i])
+		tj, _ := snapshotTime(ids[j])
+		return ti > tj
+	})
+	return ids, nil
+}
+
+// snapshotTime returns the unix time in nanoseconds of the database dir name `db-<unixnano>`
+func snapshotTime(name string) (int64, bool) {
+	if !strings.HasPrefix(name, dbDirPrefix) {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(strings.TrimPrefix(name, dbDirPrefix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
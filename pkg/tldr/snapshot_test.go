@@ -0,0 +1,113 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshots(t *testing.T) {
+	zips := make([]string, 3)
+	for i := range zips {
+		zips[i] = writeTestZip(t, []zipEntry{
+			{name: indexFileName, body: testIndex},
+			{name: "pages/common/tar.md", body: fmt.Sprintf("# tar v%d", i)},
+		})
+	}
+
+	for _, mode := range storageModes {
+		t.Run(mode.name, func(t *testing.T) {
+			srv := newSwappableZipServer(t)
+
+			tldrPath := filepath.Join(t.TempDir(), ".tldr")
+			opts := append([]Option{WithRepositoryURL(srv.URL), WithSnapshots(2)}, mode.opts...)
+			tldr := New(tldrPath, opts...)
+			t.Cleanup(func() { tldr.Close() })
+			findTar := func() string {
+				t.Helper()
+				page, err := tldr.FindPage([]string{"tar"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return page.CmdName
+			}
+
+			for _, zipPath := range zips {
+				srv.serve(zipPath)
+				if _, err := tldr.Update(context.TODO()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			snapshots, err := tldr.Snapshots()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != 2 {
+				t.Fatalf("want: 2 snapshots, got: %d", len(snapshots))
+			}
+			if !snapshots[0].Current || snapshots[1].Current {
+				t.Errorf("the newest snapshot should be current: %+v, %+v", snapshots[0], snapshots[1])
+			}
+			if snapshots[1].DownloadedAt.IsZero() || snapshots[1].SourceURL == "" {
+				t.Errorf("no manifest in the snapshot: %+v", snapshots[1])
+			}
+			if got := findTar(); got != "tar v2" {
+				t.Errorf("want: tar v2, got: %s", got)
+			}
+
+			if err := tldr.Rollback(snapshots[1].ID); err != nil {
+				t.Fatal(err)
+			}
+			if got := findTar(); got != "tar v1" {
+				t.Errorf("want: tar v1, got: %s", got)
+			}
+			if !tldr.RolledBack() {
+				t.Errorf("want: rolled back, got: not rolled back")
+			}
+
+			// Note the current snapshot is kept even if it is not the newest ones
+			srv.serve(zips[0])
+			if _, err := tldr.Update(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			if got := findTar(); got != "tar v0" {
+				t.Errorf("want: tar v0, got: %s", got)
+			}
+			if tldr.RolledBack() {
+				t.Errorf("want: not rolled back after an update, got: rolled back")
+			}
+			if err := tldr.Rollback(snapshots[0].ID); err != nil {
+				t.Fatal(err)
+			}
+			if got := findTar(); got != "tar v2" {
+				t.Errorf("want: tar v2, got: %s", got)
+			}
+		})
+	}
+}
+
+func TestRollbackInvalidSnapshot(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithTestZipURL())
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(tldrPath, dbDirPrefix+"1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", "..", "../db-1", "db-", "db-abc", "db-2", currentDBName} {
+		if err := tldr.Rollback(id); !errors.Is(err, ErrSnapshotNotFound) {
+			t.Errorf("%q: want: %v, got: %v", id, ErrSnapshotNotFound, err)
+		}
+	}
+	if err := tldr.Rollback(dbDirPrefix + "1"); err == nil || errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("want: invalid snapshot error, got: %v", err)
+	}
+	if _, err := tldr.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("the current database is broken: %v", err)
+	}
+}
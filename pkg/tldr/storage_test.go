@@ -0,0 +1,160 @@
+package tldr
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testIndex = `{"commands":[{"name":"hello","platform":["common"],"language":["en"]}]}`
+	testPage  = "# hello\n\n> Say hello.\n\n- Print hello:\n\n`hello`\n"
+)
+
+func TestWithStorage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pages", "common"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, indexFileName), []byte(testIndex), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pages", "common", "hello.md"), []byte(testPage), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(writeTestZip(t, []zipEntry{
+		{name: indexFileName, body: testIndex},
+		{name: "pages/common/hello.md", body: testPage},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { zr.Close() })
+
+	tests := []struct {
+		description string
+		fsys        fs.FS
+	}{
+		{
+			description: "in-memory",
+			fsys: fstest.MapFS{
+				indexFileName:           {Data: []byte(testIndex)},
+				"pages/common/hello.md": {Data: []byte(testPage)},
+			},
+		},
+		{
+			description: "directory",
+			fsys:        os.DirFS(dir),
+		},
+		{
+			description: "zip",
+			fsys:        zr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			// Note the data dir must not be touched
+			tldrPath := filepath.Join(t.TempDir(), ".tldr")
+			tldr := New(tldrPath, WithStorage(tt.fsys), WithLanguage("en"), WithForceUpdate())
+			if err := tldr.OnInitialize(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+			if pathExists(tldrPath) {
+				t.Errorf("data dir is created with a storage: %s", tldrPath)
+			}
+
+			page, err := tldr.FindPage([]string{"hello"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.CmdName != "hello" {
+				t.Errorf("want: hello, got: %s", page.CmdName)
+			}
+			if _, err := tldr.FindPage([]string{"..", "..", "hello"}); !errors.Is(err, ErrNotFoundPage) {
+				t.Errorf("want: %v, got: %v", ErrNotFoundPage, err)
+			}
+
+			index, err := tldr.LoadIndexFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(index.Commands) != 1 {
+				t.Errorf("want: 1 command, got: %d", len(index.Commands))
+			}
+
+			if tldr.Expired(0) {
+				t.Errorf("read-only storage should not be expired")
+			}
+			if _, err := tldr.Update(context.TODO()); !errors.Is(err, ErrReadOnlyStorage) {
+				t.Errorf("want: %v, got: %v", ErrReadOnlyStorage, err)
+			}
+		})
+	}
+
+	t.Run("no page tree", func(t *testing.T) {
+		tldr := New(t.TempDir(), WithStorage(fstest.MapFS{}))
+		if err := tldr.OnInitialize(context.TODO()); err == nil {
+			t.Errorf("expect error happens")
+		}
+	})
+}
+
+func TestWithLocalRepository(t *testing.T) {
+	repo := t.TempDir()
+	for name, body := range map[string]string{
+		"README.md":                   "# tldr-pages",
+		".git/HEAD":                   "ref: refs/heads/main",
+		"pages/common/hello.md":       testPage,
+		"pages/linux/in-review.md":    "# in-review\n\n> Not released yet.\n",
+		"pages.ja/common/hello.md":    testPage,
+		"scripts/pages/common/foo.md": "# foo",
+	} {
+		p := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tldr := New(filepath.Join(t.TempDir(), ".tldr"), WithLocalRepository(repo),
+		WithPlatform(PlatformLinux), WithLanguage("en"))
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := tldr.LoadIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Cmds{
+		{
+			Name: "hello", Platforms: []Platform{PlatformCommon}, Languages: []string{"en", "ja"},
+			Targets: []Target{{OS: PlatformCommon, Language: "en"}, {OS: PlatformCommon, Language: "ja"}},
+		},
+		{
+			Name: "in-review", Platforms: []Platform{PlatformLinux}, Languages: []string{"en"},
+			Targets: []Target{{OS: PlatformLinux, Language: "en"}},
+		},
+	}
+	if diff := cmp.Diff(want, index.Commands); diff != "" {
+		t.Errorf("-want +got\n%s", diff)
+	}
+
+	page, err := tldr.FindPage([]string{"in", "review"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.CmdName != "in-review" {
+		t.Errorf("want: in-review, got: %s", page.CmdName)
+	}
+}
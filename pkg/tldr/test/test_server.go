@@ -1,8 +1,8 @@
 package tldrtest
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -19,6 +19,7 @@ func tmpDir() string {
 type Informer interface {
 	ServerURL() string
 	TldrZipURL() string
+	TldrZipPath() string
 	Close()
 }
 
@@ -55,9 +56,23 @@ func (s *TestServer) TldrZipURL() string {
 	return s.ServerURL() + "/" + tldrZipFilename
 }
 
+// TldrZipPath returns the local path of the zip served by the server
+func (s *TestServer) TldrZipPath() string {
+	return filepath.Join(tmpDir(), tldrZipFilename)
+}
+
 func newTldrRepositoryServer() *httptest.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, tldrZipFilename+".sha256") {
+			data, err := os.ReadFile(filepath.Join(tmpDir(), tldrZipFilename))
+			if err != nil {
+				panic(err)
+			}
+			fmt.Fprintf(w, "%x  %s\n", sha256.Sum256(data), tldrZipFilename)
+			return
+		}
+
 		if !strings.HasSuffix(r.URL.Path, tldrZipFilename) {
 			fmt.Fprintf(w, "hello")
 			return
@@ -74,9 +89,14 @@ func newTldrRepositoryServer() *httptest.Server {
 		}
 		defer f.Close()
 
-		if _, err := io.Copy(w, f); err != nil {
+		fi, err := f.Stat()
+		if err != nil {
 			panic(err)
 		}
+
+		// Note ServeContent handles conditional requests with ETag and Last-Modified
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()))
+		http.ServeContent(w, r, tldrZipFilename, fi.ModTime(), f)
 	})
 
 	return httptest.NewUnstartedServer(mux)
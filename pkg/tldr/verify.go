@@ -0,0 +1,61 @@
+package tldr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const checksumSuffix = ".sha256"
+
+var (
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// WithChecksum verifies the downloaded zip against the expected SHA-256 hex string
+func WithChecksum(sum string) Option {
+	return func(t *Tldr) {
+		t.checksum = strings.ToLower(strings.TrimSpace(sum))
+	}
+}
+
+// WithChecksumVerification verifies the downloaded zip against a sidecar file.
+// The sidecar is fetched from the url of the zip with `.sha256` suffix
+func WithChecksumVerification() Option {
+	return func(t *Tldr) {
+		t.checksumSidecar = true
+	}
+}
+
+// verifyChecksum returns ErrChecksumMismatch if the zip does not match the expected checksum
+func (t *Tldr) verifyChecksum(ctx context.Context, a *archive) error {
+	expected := t.checksum
+	if expected == "" && t.checksumSidecar {
+		data, err := t.downloader.fetch(ctx, a.URL+checksumSuffix)
+		if err != nil {
+			return fmt.Errorf("failed to fetch a checksum: %w", err)
+		}
+		expected, err = parseChecksum(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if expected == "" {
+		return nil
+	}
+	if expected != a.sha256 {
+		return fmt.Errorf("%w: expected %s but got %s", ErrChecksumMismatch, expected, a.sha256)
+	}
+	return nil
+}
+
+// parseChecksum parses `sha256sum` format or a bare hex string
+func parseChecksum(data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || len(fields[0]) != 64 {
+		return "", fmt.Errorf("invalid checksum format: %q", data)
+	}
+	return strings.ToLower(fields[0]), nil
+}
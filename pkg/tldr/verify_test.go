@@ -0,0 +1,120 @@
+package tldr
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func testZipChecksum(t *testing.T) string {
+	t.Helper()
+	resp, err := http.Get(testServer.TldrZipURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func TestUpdateWithChecksum(t *testing.T) {
+	// serves a zip but a wrong sidecar checksum
+	wrongSidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if filepath.Ext(r.URL.Path) == checksumSuffix {
+			fmt.Fprintf(w, "%064d  tldr.zip\n", 0)
+			return
+		}
+		http.Redirect(w, r, testServer.TldrZipURL(), http.StatusFound)
+	}))
+	t.Cleanup(wrongSidecar.Close)
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr error
+	}{
+		{
+			name: "expected checksum",
+			opts: []Option{WithTestZipURL(), WithChecksum(testZipChecksum(t))},
+		},
+		{
+			name:    "mismatched checksum",
+			opts:    []Option{WithTestZipURL(), WithChecksum(fmt.Sprintf("%064d", 0))},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "sidecar checksum",
+			opts: []Option{WithTestZipURL(), WithChecksumVerification()},
+		},
+		{
+			name:    "mismatched sidecar checksum",
+			opts:    []Option{WithRepositoryURL(wrongSidecar.URL + "/tldr.zip"), WithChecksumVerification()},
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tldr := New(filepath.Join(t.TempDir(), ".tldr"), tt.opts...)
+			_, err := tldr.Update(context.TODO())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want: %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil && pathExists(tldr.indexFilePath()) {
+				t.Errorf("mismatched archive should not be installed")
+			}
+		})
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := fmt.Sprintf("%064x", 1)
+	tests := []struct {
+		name      string
+		data      string
+		want      string
+		expectErr bool
+	}{
+		{name: "sha256sum format", data: sum + "  tldr.zip\n", want: sum},
+		{name: "bare hex", data: sum, want: sum},
+		{name: "empty", data: "", expectErr: true},
+		{name: "short hex", data: "abcd  tldr.zip", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum([]byte(tt.data))
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expect error: %v, got: %v", tt.expectErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("want: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateWithChangedChecksum(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	if _, err := New(tldrPath, WithTestZipURL()).Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the remote zip is not modified but does not match the new checksum
+	tldr := New(tldrPath, WithTestZipURL(), WithChecksum(fmt.Sprintf("%064d", 0)))
+	_, err := tldr.Update(context.TODO())
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("want: %v, got: %v", ErrChecksumMismatch, err)
+	}
+	if _, err := tldr.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("the current database should be kept: %+v", err)
+	}
+}
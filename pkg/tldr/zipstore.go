@@ -0,0 +1,75 @@
+package tldr
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// archiveFileName is the zip kept in the database dir in zip storage mode
+const archiveFileName = "tldr.zip"
+
+// WithZipStorage keeps the downloaded zip and reads pages from it without extraction
+func WithZipStorage() Option {
+	return func(t *Tldr) {
+		t.zipStorage = true
+	}
+}
+
+// zipStore serves files in a zip through a lookup table built from the central directory.
+// It implements fs.FS
+type zipStore struct {
+	rc    *zip.ReadCloser
+	files map[string]*zip.File
+}
+
+// zipFile is a fs.File of a zip entry
+type zipFile struct {
+	io.ReadCloser
+	f *zip.File
+}
+
+func (z *zipFile) Stat() (fs.FileInfo, error) {
+	return z.f.FileInfo(), nil
+}
+
+func openZipStore(path string) (*zipStore, error) {
+	rc, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(rc.File) > maxEntries {
+		rc.Close()
+		return nil, fmt.Errorf("%w: %d entries", ErrTooManyEntries, len(rc.File))
+	}
+
+	files := make(map[string]*zip.File, len(rc.File))
+	for _, f := range rc.File {
+		if f.Mode().IsRegular() {
+			files[f.Name] = f
+		}
+	}
+	return &zipStore{rc: rc, files: files}, nil
+}
+
+// Open opens a regular file with the lookup table, otherwise falls back to zip.Reader for directories
+func (z *zipStore) Open(name string) (fs.File, error) {
+	f, ok := z.files[name]
+	if !ok {
+		return z.rc.Open(name)
+	}
+	if f.UncompressedSize64 > maxEntrySize {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: ErrEntryTooLarge}
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &zipFile{ReadCloser: rc, f: f}, nil
+}
+
+func (z *zipStore) Close() error {
+	return z.rc.Close()
+}
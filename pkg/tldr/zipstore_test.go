@@ -0,0 +1,88 @@
+package tldr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestZipStorage(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	tldr := New(tldrPath, WithTestZipURL(), WithLanguage("en"), WithZipStorage())
+	t.Cleanup(func() { tldr.Close() })
+	if err := tldr.OnInitialize(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(tldr.dbPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(entries))
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	sort.Strings(got)
+	if diff := cmp.Diff([]string{manifestFileName, archiveFileName}, got); diff != "" {
+		t.Errorf("pages should not be extracted -want +got\n%s", diff)
+	}
+
+	page, err := tldr.FindPage([]string{"git", "checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.CmdName != "git checkout" {
+		t.Errorf("want: git checkout, got: %s", page.CmdName)
+	}
+	if _, err := tldr.FindPage([]string{"lsofaaaaaaaaaaaaaaa"}); err == nil {
+		t.Errorf("expect error happens, but got response")
+	}
+
+	index, err := tldr.LoadIndexFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Commands) == 0 {
+		t.Errorf("cannot load index file as commands length is 0")
+	}
+
+	m, err := tldr.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Pages[languageCodeEN][PlatformCommon] == 0 {
+		t.Errorf("no common pages in en: %+v", m.Pages)
+	}
+}
+
+func TestSwitchStorageMode(t *testing.T) {
+	tldrPath := filepath.Join(t.TempDir(), ".tldr")
+	zipTldr := New(tldrPath, WithTestZipURL(), WithZipStorage())
+	t.Cleanup(func() { zipTldr.Close() })
+	if _, err := zipTldr.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zipTldr.FindPage([]string{"lsof"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a client without the option can read the database installed in zip storage mode
+	dirTldr := New(tldrPath, WithTestZipURL())
+	if _, err := dirTldr.FindPage([]string{"lsof"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dirTldr.Update(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if pathExists(filepath.Join(dirTldr.dbPath(), archiveFileName)) {
+		t.Errorf("zip should not remain after extraction")
+	}
+	if _, err := zipTldr.FindPage([]string{"lsof"}); err != nil {
+		t.Errorf("unexpected error got: %+v", err)
+	}
+}